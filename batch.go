@@ -0,0 +1,357 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+	"github.com/IJJA3141/GoSCII/pipeline"
+)
+
+// input is an image batch converts, found under root.
+type input struct {
+	path, root string
+}
+
+// output returns the path template gives to the render of this.
+func (this input) output(template string) string {
+	ext := filepath.Ext(this.path)
+	rel, err := filepath.Rel(this.root, this.path)
+	if err != nil {
+		rel = filepath.Base(this.path)
+	}
+
+	return filepath.Clean(strings.NewReplacer(
+		"{dir}", filepath.Dir(this.path),
+		"{name}", strings.TrimSuffix(filepath.Base(this.path), ext),
+		"{ext}", strings.TrimPrefix(ext, "."),
+		"{rel}", strings.TrimSuffix(rel, ext),
+	).Replace(template))
+}
+
+// inputs returns the images args name, directories being walked for every
+// image they hold. Images for which skip returns true are left out.
+func inputs(args []string, skip func(path string) bool) ([]input, error) {
+	var out []input
+	seen := map[string]bool{}
+	add := func(path, root string) {
+		if io.IsImage(path) && !seen[path] && !skip(path) {
+			seen[path] = true
+			out = append(out, input{path, root})
+		}
+	}
+
+	for _, arg := range args {
+		count := len(out)
+
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			err := filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+				if err == nil && entry.Type().IsRegular() {
+					add(path, arg)
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			}
+			for _, path := range matches {
+				if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+					add(path, filepath.Dir(path))
+				}
+			}
+		}
+
+		if len(out) == count && !seen[arg] {
+			return nil, fmt.Errorf("%s: no image", arg)
+		}
+	}
+
+	return out, nil
+}
+
+// excluded reports whether path or its name matches one of patterns, which
+// are valid.
+func excluded(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stampFile is the file of each output directory holding the stamps of the
+// outputs batch wrote there.
+const stampFile = ".goscii-batch.json"
+
+// stamps are the settings the outputs were written with, by output path, so
+// that changing the pipeline or the encoding converts the images again.
+type stamps struct {
+	mu sync.Mutex
+	// dirs holds the stamps of each output directory by file name, loaded
+	// on first use.
+	dirs map[string]map[string]string
+}
+
+// stampOf returns the stamp of the outputs written through pipe with image.
+func stampOf(pipe pipeline.Pipeline, image io.WriteOptions) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\n%+v", pipe, image))
+	return hex.EncodeToString(sum[:8])
+}
+
+// dir returns the stamps of the outputs of dir, loading them if needed.
+// this.mu is held.
+func (this *stamps) dir(dir string) map[string]string {
+	if stamps, ok := this.dirs[dir]; ok {
+		return stamps
+	}
+
+	stamps := map[string]string{}
+	if data, err := os.ReadFile(filepath.Join(dir, stampFile)); err == nil {
+		json.Unmarshal(data, &stamps) // an invalid file converts everything
+	}
+
+	this.dirs[dir] = stamps
+	return stamps
+}
+
+// Get returns the stamp out was written with, "" when unknown.
+func (this *stamps) Get(out string) string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	return this.dir(filepath.Dir(out))[filepath.Base(out)]
+}
+
+// Set records that out was written with stamp, writing the stamps of its
+// directory.
+func (this *stamps) Set(out, stamp string) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	dir := filepath.Dir(out)
+	stamps := this.dir(dir)
+	stamps[filepath.Base(out)] = stamp
+
+	data, err := json.MarshalIndent(stamps, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// Written beside and renamed, so that an interrupted write leaves the
+	// previous stamps.
+	tmp := filepath.Join(dir, stampFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, stampFile))
+}
+
+// upToDate reports whether out was written with stamp after path and deps
+// were last modified.
+func upToDate(out, stamp string, stamps *stamps, path string, deps ...string) bool {
+	info, err := os.Stat(out)
+	if err != nil || stamps.Get(out) != stamp {
+		return false
+	}
+
+	for _, dep := range append(deps, path) {
+		if dep, err := os.Stat(dep); err != nil || dep.ModTime().After(info.ModTime()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// convertFile renders path through pipe and writes it to out, creating its
+// directory.
+func convertFile(path, out string, pipe pipeline.Pipeline, image io.WriteOptions) error {
+	render, err := renderFile(path, pipe, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+
+	opts := io.DefaultExportOptions
+	opts.Metadata, opts.Image = render.meta, image
+	return io.WriteASCII(out, render.frames[0].(io.Cells), opts)
+}
+
+func runBatch(args []string) error {
+	var src source
+	var enc output
+	var template string
+	var jobs int
+	var force bool
+	var exclude []string
+
+	set := newFlags("batch", "<file|dir|glob>...", "Renders many images and writes each of them to the path given by -out, in which\n{dir} is the directory of the image, {name} its name without extension, {ext}\nits extension and {rel} its path relative to the directory given on the\ncommand line, without extension. Directories are walked for every image they\nhold, animations being written as their first frame.\n\nImages whose output is newer than them and than -pipeline-file, and was\nwritten with the same pipeline and encoding, are skipped unless -force is\ngiven, so that an interrupted batch resumes where it stopped. The settings\nof the outputs are kept in a .goscii-batch.json file in their directory.\nA file that fails to convert does not stop the others, the failures being\nlisted at the end.")
+	src.registerPipeline(set)
+	set.String(&template, "out", "o", "{dir}/{name}.txt", "template of the output paths, PNG, JPEG, GIF, .svg, .html, .txt, .ans or .json")
+	enc.register(set)
+	set.Int(&jobs, "jobs", "j", runtime.GOMAXPROCS(0), "number of images converted at once")
+	set.Bool(&force, "force", "F", false, "convert images whose output is up to date")
+	set.List(&exclude, "exclude", "x", "glob pattern of the images to skip, matched against their path and their name")
+	if err := parse(set, args); err != nil {
+		return err
+	}
+
+	if jobs < 1 {
+		return fmt.Errorf("jobs must be at least 1")
+	}
+	for _, pattern := range exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("exclude %s: %w", pattern, err)
+		}
+	}
+	if len(set.Args()) == 0 {
+		return fmt.Errorf("no image to convert, see goscii batch -h")
+	}
+
+	image, err := enc.options()
+	if err != nil {
+		return err
+	}
+	pipe, err := src.pipe()
+	if err != nil {
+		return err
+	}
+	// Outputs written among the images by previous runs are not converted
+	// in turn.
+	written := &stamps{dirs: map[string]map[string]string{}}
+	images, err := inputs(set.Args(), func(path string) bool {
+		return excluded(path, exclude) || written.Get(path) != ""
+	})
+	if err != nil {
+		return err
+	}
+
+	// Nor are those without a stamp, such as outputs of an interrupted run,
+	// that another image is written to.
+	targets := map[string]bool{}
+	for _, in := range images {
+		targets[in.output(template)] = true
+	}
+	images = slices.DeleteFunc(images, func(in input) bool {
+		path := filepath.Clean(in.path)
+		return targets[path] && in.output(template) != path
+	})
+
+	outs := make([]string, len(images))
+	owners := map[string]string{}
+	for i, in := range images {
+		outs[i] = in.output(template)
+		if owner, ok := owners[outs[i]]; ok {
+			return fmt.Errorf("%s and %s are both written to %s", owner, in.path, outs[i])
+		}
+		if outs[i] == filepath.Clean(in.path) {
+			return fmt.Errorf("%s would be written over itself", in.path)
+		}
+		owners[outs[i]] = in.path
+	}
+
+	var deps []string
+	if src.pipelineFile != "" {
+		deps = append(deps, src.pipelineFile)
+	}
+
+	stamp := stampOf(pipe, image)
+
+	filters.SetWorkers(src.workers)
+	start := time.Now()
+
+	var mu sync.Mutex
+	var done, converted, skipped int
+	errs := make([]error, len(images))
+
+	// report prints the progress once image i is done.
+	report := func(i int, status string, took time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		done++
+		line := fmt.Sprintf("[%*d/%d] %s %s", len(fmt.Sprint(len(images))), done, len(images), status, images[i].path)
+		switch {
+		case errs[i] != nil:
+			line += ": " + errs[i].Error()
+		case status == "converted":
+			converted++
+			line += " -> " + outs[i] + " (" + round(took) + ")"
+		default:
+			skipped++
+		}
+		fmt.Fprintln(os.Stderr, line)
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, len(images)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				if !force && upToDate(outs[i], stamp, written, images[i].path, deps...) {
+					report(i, "up to date", 0)
+					continue
+				}
+
+				start := time.Now()
+				errs[i] = convertFile(images[i].path, outs[i], pipe, image)
+				if errs[i] == nil {
+					errs[i] = written.Set(outs[i], stamp)
+				}
+				if errs[i] != nil {
+					report(i, "failed", 0)
+					continue
+				}
+				report(i, "converted", time.Since(start))
+			}
+		}()
+	}
+
+	for i := range images {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	failed := len(images) - converted - skipped
+	fmt.Fprintf(os.Stderr, "\n%d converted, %d up to date, %d failed in %s\n", converted, skipped, failed, round(time.Since(start)))
+	if failed == 0 {
+		return nil
+	}
+
+	var summary []error
+	for i, err := range errs {
+		if err != nil {
+			summary = append(summary, fmt.Errorf("  %s: %w", images[i].path, err))
+		}
+	}
+	return fmt.Errorf("%d of %d images failed:\n%w", failed, len(images), errors.Join(summary...))
+}
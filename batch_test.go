@@ -0,0 +1,96 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/config"
+)
+
+// writeImage writes a gray gradient to path.
+func writeImage(t *testing.T, path string) {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 32, 32))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchStamps(t *testing.T) {
+	cfg = &config.Config{}
+
+	dir := t.TempDir()
+	writeImage(t, filepath.Join(dir, "image.png"))
+
+	out := filepath.Join(dir, "image.txt")
+	batch := func(args ...string) time.Time {
+		t.Helper()
+
+		if err := runBatch(append(args, dir)); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.ModTime()
+	}
+
+	first := batch("-width", "10")
+
+	// The output is newer than the image, only the change of the flags
+	// tells it apart from an up to date one.
+	time.Sleep(10 * time.Millisecond)
+	if batch("-width", "10") != first {
+		t.Error("up to date output was converted again")
+	}
+	if batch("-width", "12") == first {
+		t.Error("output of another width was kept")
+	}
+}
+
+func TestBatchOwnOutputs(t *testing.T) {
+	cfg = &config.Config{}
+
+	dir := t.TempDir()
+	writeImage(t, filepath.Join(dir, "image.png"))
+
+	batch := func() {
+		t.Helper()
+
+		if err := runBatch([]string{"-out", "{dir}/{name}_ascii.png", "-width", "10", dir}); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if entry.Name() == "image_ascii_ascii.png" {
+				t.Fatal("the output of a previous run was converted")
+			}
+		}
+	}
+
+	batch()
+	batch()
+
+	// Outputs without a stamp are still told apart by the template.
+	if err := os.Remove(filepath.Join(dir, stampFile)); err != nil {
+		t.Fatal(err)
+	}
+	batch()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+	"github.com/IJJA3141/GoSCII/pipeline"
+)
+
+func runBench(args []string) error {
+	var text string
+	var workers, count int
+
+	set := newFlags("bench", "[image...]", "Times the decoding of every image and the pipeline on it, stage by stage. The\ntimes are averaged over -count runs, after a warm-up one. Images default to\nexample_images/*.")
+	set.String(&text, "pipeline", "p", pipeline.Default, "filters the images go through")
+	set.Int(&workers, "workers", "w", runtime.GOMAXPROCS(0), "number of goroutines the filters run on")
+	set.Int(&count, "count", "c", 10, "number of runs per image")
+	if err := parse(set, args); err != nil {
+		return err
+	}
+
+	pipe, err := pipeline.Parse(text)
+	if err != nil {
+		return err
+	}
+	if count < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+
+	paths := set.Args()
+	if len(paths) == 0 {
+		if paths, err = filepath.Glob("example_images/*"); err != nil {
+			return err
+		}
+	}
+
+	filters.SetWorkers(workers)
+	fmt.Printf("%s, %d workers, %d runs\n\n", pipe, workers, count)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := []string{"image", "size", "decode", "total"}
+	for _, stage := range pipe {
+		header = append(header, stage.Name)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+
+	for _, path := range paths {
+		start := time.Now()
+		img, err := io.Read(path)
+		if err != nil {
+			return err
+		}
+		decode := time.Since(start)
+
+		if _, err := pipe.Run(context.Background(), img); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		var total time.Duration
+		stages := make([]time.Duration, len(pipe))
+		for range count {
+			start := time.Now()
+			result, err := pipe.Run(context.Background(), img)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			total += time.Since(start)
+
+			for i, t := range result.Times {
+				stages[i] += t
+			}
+		}
+
+		row := []string{filepath.Base(path), fmt.Sprintf("%dx%d", img.Width, img.Height), round(decode), round(total / time.Duration(count))}
+		for _, t := range stages {
+			row = append(row, round(t/time.Duration(count)))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+
+	return w.Flush()
+}
+
+// round rounds d to three significant digits.
+func round(d time.Duration) string {
+	for unit := time.Duration(1); unit < time.Hour; unit *= 10 {
+		if d < 1000*unit {
+			return d.Round(unit).String()
+		}
+	}
+
+	return d.String()
+}
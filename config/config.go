@@ -0,0 +1,213 @@
+// Package config reads the user configuration of goscii, a TOML file such as
+//
+//	# defaults of the flags of every command
+//	renderer = "braille"
+//	workers = 4
+//
+//	[profile.portrait]
+//	renderer = "ascii"
+//	palette = " .:-=+*#%@"
+//	aspect = 2.2
+//
+//	[theme]
+//	normal-bg = "#646464"
+//
+//	[keys]
+//	left = "a"
+//
+// Values are strings, numbers or booleans, which the flags parse, and arrays
+// of them for the flags that can be repeated, such as exclude = ["*.gif"].
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config is the content of a config file.
+type Config struct {
+	// Flags holds the defaults of the flags, by flag name. Arrays hold one
+	// value per element, for repeatable flags.
+	Flags map[string][]string
+
+	// Profiles holds the [profile.<name>] tables, whose values replace those
+	// of Flags when the profile is selected.
+	Profiles map[string]map[string][]string
+
+	// Theme and Keys hold the [theme] and [keys] tables.
+	Theme map[string]string
+	Keys  map[string]string
+}
+
+// Path returns the path of the config file: $GOSCII_CONFIG, or
+// goscii/config.toml in the user config directory, ~/.config on Linux.
+func Path() string {
+	if path := os.Getenv("GOSCII_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "goscii", "config.toml")
+}
+
+// Load reads the config file at path. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	out := &Config{
+		Flags:    map[string][]string{},
+		Profiles: map[string]map[string][]string{},
+		Theme:    map[string]string{},
+		Keys:     map[string]string{},
+	}
+
+	var doc map[string]any
+	if _, err := toml.DecodeFile(path, &doc); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return out, nil
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for key, value := range doc {
+		var err error
+
+		switch key {
+		case "profile":
+			profiles, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: profile: expected [profile.<name>] tables", path)
+			}
+			for name, table := range profiles {
+				out.Profiles[name] = map[string][]string{}
+				err = lists(out.Profiles[name], table)
+				if err != nil {
+					err = fmt.Errorf("[profile.%s]: %w", name, err)
+					break
+				}
+			}
+
+		case "theme", "keys":
+			table := out.Theme
+			if key == "keys" {
+				table = out.Keys
+			}
+			if err = scalars(table, value); err != nil {
+				err = fmt.Errorf("[%s]: %w", key, err)
+			}
+
+		default:
+			if _, ok := value.(map[string]any); ok {
+				err = fmt.Errorf("unknown table [%s]", key)
+			} else {
+				out.Flags[key], err = list(key, value)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return out, nil
+}
+
+// scalars sets the keys of out from table, whose values have to be scalars.
+func scalars(out map[string]string, table any) error {
+	values, ok := table.(map[string]any)
+	if !ok {
+		return fmt.Errorf("expected a table")
+	}
+
+	for key, value := range values {
+		v, err := scalar(key, value)
+		if err != nil {
+			return err
+		}
+		out[key] = v
+	}
+
+	return nil
+}
+
+// lists sets the keys of out from table, whose values are scalars or arrays
+// of them.
+func lists(out map[string][]string, table any) error {
+	values, ok := table.(map[string]any)
+	if !ok {
+		return fmt.Errorf("expected a table")
+	}
+
+	for key, value := range values {
+		v, err := list(key, value)
+		if err != nil {
+			return err
+		}
+		out[key] = v
+	}
+
+	return nil
+}
+
+// list formats the value of key, a scalar or an array of them, as a value per
+// element.
+func list(key string, value any) ([]string, error) {
+	array, ok := value.([]any)
+	if !ok {
+		v, err := scalar(key, value)
+		return []string{v}, err
+	}
+
+	out := make([]string, len(array))
+	for i, value := range array {
+		var err error
+		if out[i], err = scalar(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// scalar formats the string, number or boolean value of key as flags parse
+// it.
+func scalar(key string, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+
+	return "", fmt.Errorf("%s: expected a string, number or boolean, not %T", key, value)
+}
+
+// Defaults returns the flag defaults of profile, the top level values
+// overridden by those of the profile when it is not empty.
+func (this *Config) Defaults(profile string) (map[string][]string, error) {
+	out := maps.Clone(this.Flags)
+	if profile == "" {
+		return out, nil
+	}
+
+	values, ok := this.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", profile)
+	}
+	maps.Copy(out, values)
+
+	return out, nil
+}
@@ -0,0 +1,131 @@
+package config_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/config"
+)
+
+func write(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := config.Load(write(t, `# defaults
+renderer = "braille"
+workers = 4   # a comment
+aspect = 2.5
+print = true
+tag = 'b#c'
+count = 1_000
+exclude = ["*.gif", 'a#b', 2]
+
+[profile.portrait]
+renderer = "ascii"
+palette = " .:\"#"
+
+[theme]
+normal-bg = "#646464"
+
+[keys]
+left = "a"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"renderer": {"braille"}, "workers": {"4"}, "aspect": {"2.5"}, "print": {"true"},
+		"tag": {"b#c"}, "count": {"1000"}, "exclude": {"*.gif", "a#b", "2"},
+	}
+	if !maps.EqualFunc(cfg.Flags, want, slices.Equal) {
+		t.Errorf("Flags = %v, want %v", cfg.Flags, want)
+	}
+	if cfg.Theme["normal-bg"] != "#646464" || cfg.Keys["left"] != "a" {
+		t.Errorf("Theme = %v, Keys = %v", cfg.Theme, cfg.Keys)
+	}
+
+	defaults, err := cfg.Defaults("portrait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaults["renderer"][0] != "ascii" || defaults["palette"][0] != ` .:"#` || defaults["workers"][0] != "4" {
+		t.Errorf("portrait defaults = %v", defaults)
+	}
+	if cfg.Flags["renderer"][0] != "braille" {
+		t.Error("Defaults modified the top level values")
+	}
+	if _, err := cfg.Defaults("landscape"); err == nil {
+		t.Error("unknown profile was accepted")
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	// Quoted and dotted keys, inline tables and TOML escapes, which a
+	// subset of the format would get wrong.
+	cfg, err := config.Load(write(t, `"menu-width" = 60
+palette = "\u00e9\t#"
+literal = 'C:\path'
+profile.small = { width = 40, color = "none" }
+multi = """
+a\
+  b"""
+
+[keys]
+"left" = "a"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{"menu-width": {"60"}, "palette": {"\u00e9\t#"}, "literal": {`C:\path`}, "multi": {"ab"}}
+	if !maps.EqualFunc(cfg.Flags, want, slices.Equal) {
+		t.Errorf("Flags = %q, want %q", cfg.Flags, want)
+	}
+	if want := map[string][]string{"width": {"40"}, "color": {"none"}}; !maps.EqualFunc(cfg.Profiles["small"], want, slices.Equal) {
+		t.Errorf("profile small = %v, want %v", cfg.Profiles["small"], want)
+	}
+	if cfg.Keys["left"] != "a" {
+		t.Errorf("Keys = %v", cfg.Keys)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "none.toml"))
+	if err != nil || len(cfg.Flags) != 0 {
+		t.Errorf("missing file gives %v, %v", cfg, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, content := range []string{
+		"renderer = ascii",
+		"renderer",
+		`renderer = "ascii`,
+		"[colors]",
+		"[theme\nx = 1",
+		"tag = [1, [2]]",
+		"tag = [1, { a = 2 }]",
+		`workers = 4 5`,
+		`[keys]` + "\n" + `left = ["a", "b"]`,
+		"created = 1979-05-27",
+		"[profile]\nrenderer = \"ascii\"",
+		"[profile.small.nested]\nwidth = 40",
+		"[theme]\nnormal-bg = { r = 1 }",
+	} {
+		if _, err := config.Load(write(t, content)); err == nil {
+			t.Errorf("Load accepted %q", content)
+		}
+	}
+}
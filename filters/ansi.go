@@ -0,0 +1,331 @@
+package filters
+
+import (
+	"context"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Profile is the set of colors a terminal can display.
+type Profile int
+
+const (
+	// TrueColor terminals display 24-bit colors.
+	TrueColor Profile = iota
+	// ANSI256 terminals display the xterm 256 color palette.
+	ANSI256
+	// ANSI16 terminals display the 16 standard colors.
+	ANSI16
+	// NoColor terminals only display attributes.
+	NoColor
+)
+
+// DetectProfile guesses the profile of the terminal from the environment,
+// following the NO_COLOR, COLORTERM and TERM conventions.
+func DetectProfile() Profile {
+	term := os.Getenv("TERM")
+
+	switch {
+	case os.Getenv("NO_COLOR") != "" || term == "dumb":
+		return NoColor
+
+	case os.Getenv("COLORTERM") == "truecolor" || os.Getenv("COLORTERM") == "24bit":
+		return TrueColor
+
+	case strings.Contains(term, "256color"):
+		return ANSI256
+	}
+
+	return ANSI16
+}
+
+// Encoder turns cells into text with the SGR escape sequences of a terminal
+// profile.
+//
+// The output is kept minimal: a single combined escape sequence is emitted
+// when the colors or attributes change between adjacent cells, none
+// otherwise, and lines end with a reset only when anything was set.
+type Encoder struct {
+	Profile Profile
+
+	// Tolerance is the largest difference, on any channel, for which a
+	// TrueColor cell keeps the color currently set instead of emitting its
+	// own. It trades exact colors for much shorter output on gradients.
+	Tolerance uint8
+}
+
+// DefaultEncoder is the encoder used by AsciiColorPlane.Buffer and Get.
+var DefaultEncoder = Encoder{Profile: TrueColor}
+
+// attrCodes are the SGR codes of the attributes, in bit order.
+var attrCodes = [...]string{"1", "2", "3", "4", "5", "7", "9"}
+
+// Encode encodes a line of cells.
+func (e Encoder) Encode(cells []Cell) string {
+	var b strings.Builder
+	b.Grow(len(cells))
+
+	// prev is the state of the terminal, starting from the default one.
+	reset := state{fg: -1, bg: -1}
+	prev := reset
+	params := make([]string, 0, 8)
+
+	for _, cell := range cells {
+		next := e.state(cell)
+		next.fg, next.bg = e.keep(prev.fg, next.fg), e.keep(prev.bg, next.bg)
+
+		if next != prev {
+			params = params[:0]
+
+			if prev.attr&^next.attr != 0 {
+				// Attributes can only be removed all at once.
+				params = append(params, "0")
+				prev = reset
+			}
+
+			for bit, code := range attrCodes {
+				if added := next.attr &^ prev.attr; added&(1<<bit) != 0 {
+					params = append(params, code)
+				}
+			}
+
+			if next.fg != prev.fg {
+				params = append(params, e.params(next.fg, false))
+			}
+			if next.bg != prev.bg {
+				params = append(params, e.params(next.bg, true))
+			}
+
+			b.WriteString("\x1B[" + strings.Join(params, ";") + "m")
+		}
+
+		prev = next
+		b.WriteRune(cell.Rune)
+	}
+
+	if prev != reset {
+		b.WriteString("\x1B[0m")
+	}
+
+	return b.String()
+}
+
+// EncodePlane encodes every row of plane.
+func (e Encoder) EncodePlane(plane *AsciiColorPlane) []string {
+	out := make([]string, plane.Height)
+
+	rows(context.Background(), plane.Height, func(_start, _end int) {
+		for y := _start; y < _end; y++ {
+			out[y] = e.Encode(plane.Cells[y*plane.Stride : y*plane.Stride+plane.Width])
+		}
+	})
+
+	return out
+}
+
+// diffGap is the number of unchanged cells under which two changed spans of
+// a row are encoded as one, redrawing the cells between them being shorter
+// than the cursor move that would skip them.
+const diffGap = 6
+
+// EncodeDiff returns the text turning the screen showing prev, drawn from the
+// top left corner, into next: only the spans of cells that differ are
+// encoded, each preceded by the escape moving the cursor to it. The whole of
+// next is drawn, after clearing the screen, when prev is nil or of another
+// size.
+func (e Encoder) EncodeDiff(prev, next Cells) string {
+	full := prev == nil || prev.Width_() != next.Width_() || prev.Height_() != next.Height_()
+
+	var b strings.Builder
+	if full {
+		b.WriteString("\x1B[H\x1B[2J")
+	}
+
+	row := make([]Cell, next.Width_())
+	for y := range next.Height_() {
+		for x := range row {
+			row[x] = next.At(x, y)
+		}
+
+		for x := 0; x < len(row); {
+			if !full && prev.At(x, y) == row[x] {
+				x++
+				continue
+			}
+
+			// The span runs to the last changed cell followed by fewer
+			// than diffGap unchanged ones.
+			end, same := x+1, 0
+			for i := x + 1; i < len(row) && same < diffGap; i++ {
+				if full || prev.At(i, y) != row[i] {
+					end, same = i+1, 0
+				} else {
+					same++
+				}
+			}
+
+			b.WriteString("\x1B[" + strconv.Itoa(y+1) + ";" + strconv.Itoa(x+1) + "H")
+			b.WriteString(e.Encode(row[x:end]))
+			x = end
+		}
+	}
+
+	return b.String()
+}
+
+// keep returns the color index to display instead of next when prev is
+// currently set, according to the tolerance of e.
+func (e Encoder) keep(prev, next int) int {
+	if e.Tolerance == 0 || e.Profile != TrueColor || prev < 0 || next < 0 {
+		return next
+	}
+
+	for shift := 0; shift < 24; shift += 8 {
+		d := prev>>shift&0xff - next>>shift&0xff
+		if d > int(e.Tolerance) || -d > int(e.Tolerance) {
+			return next
+		}
+	}
+
+	return prev
+}
+
+// state is what the terminal displays a cell with. Colors are indices in the
+// palette of the profile, or packed RGB values for TrueColor, and -1 for the
+// default color.
+type state struct {
+	fg, bg int
+	attr   Attr
+}
+
+func (e Encoder) state(cell Cell) state {
+	return state{fg: e.index(cell.FG), bg: e.index(cell.BG), attr: cell.Attr}
+}
+
+// index returns the palette index of c in the profile of e.
+func (e Encoder) index(c color.RGBA) int {
+	if c.A == 0 {
+		return -1
+	}
+
+	switch e.Profile {
+	case TrueColor:
+		return int(c.R)<<16 | int(c.G)<<8 | int(c.B)
+
+	case ANSI256:
+		return index256(c)
+
+	case ANSI16:
+		return nearest(c, Palette16[:])
+	}
+
+	return -1
+}
+
+// params returns the SGR parameters selecting the color of the given index.
+func (e Encoder) params(index int, background bool) string {
+	if index < 0 {
+		if background {
+			return "49"
+		}
+		return "39"
+	}
+
+	switch e.Profile {
+	case TrueColor:
+		prefix := "38;2;"
+		if background {
+			prefix = "48;2;"
+		}
+		return prefix + strconv.Itoa(index>>16) + ";" + strconv.Itoa(index>>8&0xff) + ";" + strconv.Itoa(index&0xff)
+
+	case ANSI256:
+		if background {
+			return "48;5;" + strconv.Itoa(index)
+		}
+		return "38;5;" + strconv.Itoa(index)
+	}
+
+	// ANSI16
+	base := 30
+	if background {
+		base = 40
+	}
+	if index >= 8 {
+		base += 60 - 8
+	}
+	return strconv.Itoa(base + index)
+}
+
+// Palette16 holds the default xterm values of the 16 standard colors.
+var Palette16 = [16]color.RGBA{
+	{0, 0, 0, 0xff}, {205, 0, 0, 0xff}, {0, 205, 0, 0xff}, {205, 205, 0, 0xff},
+	{0, 0, 238, 0xff}, {205, 0, 205, 0xff}, {0, 205, 205, 0xff}, {229, 229, 229, 0xff},
+	{127, 127, 127, 0xff}, {255, 0, 0, 0xff}, {0, 255, 0, 0xff}, {255, 255, 0, 0xff},
+	{92, 92, 255, 0xff}, {255, 0, 255, 0xff}, {0, 255, 255, 0xff}, {255, 255, 255, 0xff},
+}
+
+// cubeLevels are the channel values of the 6x6x6 cube of the 256 colors.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// Color256 returns the color of index n of the xterm 256 color palette.
+func Color256(n uint8) color.RGBA {
+	switch {
+	case n < 16:
+		return Palette16[n]
+
+	case n < 232:
+		n -= 16
+		return color.RGBA{uint8(cubeLevels[n/36]), uint8(cubeLevels[n/6%6]), uint8(cubeLevels[n%6]), 0xff}
+	}
+
+	v := 8 + 10*(n-232)
+	return color.RGBA{v, v, v, 0xff}
+}
+
+// index256 returns the closest of the 6x6x6 cube and of the gray ramp of the
+// 256 color palette.
+func index256(c color.RGBA) int {
+	level := func(v uint8) int {
+		switch {
+		case v < 48:
+			return 0
+		case v < 115:
+			return 1
+		}
+		return (int(v) - 35) / 40
+	}
+
+	r, g, b := level(c.R), level(c.G), level(c.B)
+	cube := color.RGBA{uint8(cubeLevels[r]), uint8(cubeLevels[g]), uint8(cubeLevels[b]), 0xff}
+
+	// The gray ramp goes from 8 to 238 by steps of 10.
+	mean := (int(c.R) + int(c.G) + int(c.B)) / 3
+	k := min(max((mean-3)/10, 0), 23)
+	gray := color.RGBA{uint8(8 + 10*k), uint8(8 + 10*k), uint8(8 + 10*k), 0xff}
+
+	if distance(c, gray) < distance(c, cube) {
+		return 232 + k
+	}
+	return 16 + 36*r + 6*g + b
+}
+
+// nearest returns the index of the color of palette closest to c.
+func nearest(c color.RGBA, palette []color.RGBA) int {
+	best := 0
+	for i := range palette {
+		if distance(c, palette[i]) < distance(c, palette[best]) {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// distance is the squared euclidean distance between two colors.
+func distance(a, b color.RGBA) int {
+	dr, dg, db := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+	return dr*dr + dg*dg + db*db
+}
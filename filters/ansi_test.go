@@ -0,0 +1,71 @@
+package filters_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+func TestEncoder(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	darkRed := color.RGBA{250, 10, 0, 255}
+	gray := color.RGBA{128, 128, 128, 255}
+
+	tests := []struct {
+		name      string
+		profile   filters.Profile
+		tolerance uint8
+		cells     []filters.Cell
+		want      string
+	}{
+		{
+			name:  "default colors",
+			cells: []filters.Cell{{Rune: 'a'}, {Rune: 'b'}},
+			want:  "ab",
+		},
+		{
+			name:  "escape on change only",
+			cells: []filters.Cell{{Rune: 'a', FG: red}, {Rune: 'b', FG: red}, {Rune: 'c', FG: gray}, {Rune: 'd'}},
+			want:  "\x1b[38;2;255;0;0mab\x1b[38;2;128;128;128mc\x1b[39md",
+		},
+		{
+			name:  "background and attributes",
+			cells: []filters.Cell{{Rune: 'a', BG: red, Attr: filters.Bold | filters.Underline}, {Rune: 'b', Attr: filters.Bold}},
+			want:  "\x1b[1;4;48;2;255;0;0ma\x1b[0;1mb\x1b[0m",
+		},
+		{
+			name:    "256 colors merge close colors",
+			profile: filters.ANSI256,
+			cells:   []filters.Cell{{Rune: 'a', FG: red}, {Rune: 'b', FG: darkRed}, {Rune: 'c', FG: gray}},
+			want:    "\x1b[38;5;196mab\x1b[38;5;244mc\x1b[0m",
+		},
+		{
+			name:      "tolerance keeps the color set",
+			tolerance: 10,
+			cells:     []filters.Cell{{Rune: 'a', FG: red}, {Rune: 'b', FG: darkRed}, {Rune: 'c', FG: color.RGBA{242, 0, 0, 255}}},
+			want:      "\x1b[38;2;255;0;0mab\x1b[38;2;242;0;0mc\x1b[0m",
+		},
+		{
+			name:    "16 colors",
+			profile: filters.ANSI16,
+			cells:   []filters.Cell{{Rune: 'a', FG: red, BG: gray}},
+			want:    "\x1b[91;100ma\x1b[0m",
+		},
+		{
+			name:    "no color keeps attributes",
+			profile: filters.NoColor,
+			cells:   []filters.Cell{{Rune: 'a', FG: red, Attr: filters.Reverse}},
+			want:    "\x1b[7ma\x1b[0m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filters.Encoder{Profile: tt.profile, Tolerance: tt.tolerance}.Encode(tt.cells)
+			if got != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
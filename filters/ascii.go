@@ -1,11 +1,11 @@
 package filters
 
 import (
+	"context"
 	_ "errors"
 	"fmt"
+	"image/color"
 	"math"
-	"strconv"
-	"strings"
 )
 
 type Stampable interface {
@@ -23,12 +23,8 @@ type Ascii interface {
 // Colorize converts an AsciiPlane into an AsciiColorPlane using an RGBAPlane
 // as the source of color information.
 //
-// Each ASCII character is prefixed with an ANSI 24-bit color escape sequence
-// based on the corresponding RGB pixel in the colors plane. The alpha channel
-// is ignored.
-//
-// If consecutive pixels share the same RGB color, the ANSI escape sequence
-// is reused to reduce redundant escape codes.
+// Each character takes the RGB color of the corresponding pixel of the
+// colors plane as its foreground color. The alpha channel is ignored.
 //
 // Parameters:
 //   - colors: the RGBAPlane providing per-pixel color information
@@ -45,32 +41,19 @@ func (ascii *AsciiPlane) Colorize(colors *RGBAPlane) (*AsciiColorPlane, error) {
 
 	out := NewAsciiColorPlane(ascii.Width, ascii.Height)
 
-	split(out.Height, func(_start, _end int) {
-		// var prevR, prevG, prevB uint8 = uint8(colors.RGBA[_start*colors.Stride]) - 1, 0, 0
-
-		for y := _start; y < _end && y < out.Height; y++ {
-			for x := range out.Width {
+	parallel(context.Background(), out.Width, out.Height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
 				index := y*colors.Stride + x*4
-				r := uint8(colors.RGBA[index])
-				g := uint8(colors.RGBA[index+1])
-				b := uint8(colors.RGBA[index+2])
 				// a:=uint8(_colors.RGBA[index+3]) // might take the alpha in consideration
 
-				// if r == prevR && g == prevG && b == prevB {
-				// 	out.Chars[y*out.Stride+x] = string(ascii.Chars[y*ascii.Stride+x])
-				// } else {
-				out.Chars[y*out.Stride+x] =
-					"\x1B[38;2;" + strconv.FormatUint(uint64(r), 10) +
-						";" + strconv.FormatUint(uint64(g), 10) +
-						";" + strconv.FormatUint(uint64(b), 10) + "m" + string(ascii.Chars[y*ascii.Stride+x])
-
-				// prevR = r
-				// prevG = g
-				// prevB = b
-				// }
+				out.Cells[y*out.Stride+x] = Cell{
+					Rune: ascii.Chars[y*ascii.Stride+x],
+					FG:   color.RGBA{uint8(colors.RGBA[index]), uint8(colors.RGBA[index+1]), uint8(colors.RGBA[index+2]), 0xff},
+				}
 			}
 		}
-	}).Wait()
+	})
 
 	return out, nil
 }
@@ -88,19 +71,20 @@ func (ascii *AsciiPlane) Colorize(colors *RGBAPlane) (*AsciiColorPlane, error) {
 //   - A new AsciiPlane where each pixel is replaced by a corresponding character
 func (img *GrayScalePlane) Ascii(palette []rune) *AsciiPlane {
 	out := NewAsciiPlane(img.Width, img.Height)
+	ascii(img.view(), out, palette)
+	return out
+}
 
-	split(out.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < out.Height; y++ {
-			for x := range out.Width {
-				index := y*out.Stride + x
-				lum := img.Shades[index]                              // 0 .. 255
+func ascii[T sample](img plane[T], out *AsciiPlane, palette []rune) {
+	parallel(context.Background(), out.Width, out.Height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				lum := float64(img.pix[y*img.stride+x])               // 0 .. 255
 				bucket := int((lum / 255.) * float64(len(palette)-1)) // index in palette
-				out.Chars[index] = palette[bucket]
+				out.Chars[y*out.Stride+x] = palette[bucket]
 			}
 		}
-	}).Wait()
-
-	return out
+	})
 }
 
 const π2 = math.Pi * 2
@@ -108,9 +92,9 @@ const π2 = math.Pi * 2
 func (img *EdgePlane) Ascii(threshold float64, palette []rune) *AsciiPlane {
 	out := NewAsciiPlane(img.Width, img.Height)
 
-	split(out.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < out.Height; y++ {
-			for x := range out.Width {
+	parallel(context.Background(), out.Width, out.Height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
 				index := y*img.Stride + x*2
 				magnitude := img.Gradient[index]
 				angle := img.Gradient[index+1] // 0 .. 2pi
@@ -123,7 +107,7 @@ func (img *EdgePlane) Ascii(threshold float64, palette []rune) *AsciiPlane {
 				}
 			}
 		}
-	}).Wait()
+	})
 
 	return out
 }
@@ -154,20 +138,24 @@ var dotMatrix = [][]uint16{
 // Notes:
 //   - The output width is img.Width / 2, and the output height is img.Height / 4
 //   - Pixels are grouped top-to-bottom, left-to-right in the 2×4 block
-//   - The function is parallelized across rows for performance
+//   - The function is parallelized across tiles for performance
 func (img *GrayScalePlane) Braille(threshold float64) *AsciiPlane {
 	out := NewAsciiPlane(img.Width/2, img.Height/4)
+	braille(img.view(), out, threshold)
+	return out
+}
 
-	split(out.Height, func(_start, _end int) {
+func braille[T sample](img plane[T], out *AsciiPlane, threshold float64) {
+	parallel(context.Background(), out.Width, out.Height, func(x0, y0, x1, y1 int) {
 		var char uint16
 
-		for y := _start; y < _end && y < out.Height; y++ {
-			for x := range out.Width {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
 				char = 0x2800 // block offset
 
 				for j := range 4 {
 					for i := range 2 {
-						if img.Shades[(y*4+j)*img.Stride+(x*2+i)] >= threshold {
+						if float64(img.pix[(y*4+j)*img.stride+(x*2+i)]) >= threshold {
 							char += dotMatrix[j][i]
 						}
 					}
@@ -176,9 +164,7 @@ func (img *GrayScalePlane) Braille(threshold float64) *AsciiPlane {
 				out.Chars[y*out.Stride+x] = rune(char)
 			}
 		}
-	}).Wait()
-
-	return out
+	})
 }
 
 // idee de merde il faudrait pouvoir avoir des bords d'une couleur differancte.
@@ -191,20 +177,25 @@ func (this *AsciiPlane) Height_() int { return this.Height }
 func (this *AsciiPlane) Buffer() []string {
 	out := make([]string, this.Height)
 
-	split(this.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < this.Height; y++ {
+	rows(context.Background(), this.Height, func(_start, _end int) {
+		for y := _start; y < _end; y++ {
 			out[y] = string(this.Chars[y*this.Stride : y*this.Stride+this.Width])
 		}
-	}).Wait()
+	})
 
 	return out
 }
 
+// Stamp returns the dimensions of the plane and every cell encoded on its
+// own by DefaultEncoder.
 func (img *AsciiColorPlane) Stamp() (int, int, [][]string) {
 	out := make([][]string, img.Height)
 
 	for y := range img.Height {
-		out[y] = img.Chars[y*img.Stride : y*img.Stride+img.Width]
+		out[y] = make([]string, img.Width)
+		for x, cell := range img.Cells[y*img.Stride : y*img.Stride+img.Width] {
+			out[y][x] = DefaultEncoder.Encode([]Cell{cell})
+		}
 	}
 
 	return img.Width, img.Height, out
@@ -213,15 +204,7 @@ func (img *AsciiColorPlane) Stamp() (int, int, [][]string) {
 func (this *AsciiColorPlane) Width_() int  { return this.Width }
 func (this *AsciiColorPlane) Height_() int { return this.Height }
 func (this *AsciiColorPlane) Buffer() []string {
-	out := make([]string, this.Height)
-
-	split(this.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < this.Height; y++ {
-			out[y] = strings.Join(this.Chars[y*this.Stride:y*this.Stride+this.Width], "")
-		}
-	}).Wait()
-
-	return out
+	return DefaultEncoder.EncodePlane(this)
 }
 
 func (this *AsciiPlane) Get(x, y, width, height int) []string {
@@ -240,8 +223,19 @@ func (this *AsciiColorPlane) Get(x, y, width, height int) []string {
 
 	for i := range height {
 		index := (y+i)*this.Stride + x
-		out[i] = strings.Join((this.Chars[index : index+width]), "")
+		out[i] = DefaultEncoder.Encode(this.Cells[index : index+width])
 	}
 
 	return out
 }
+
+// At returns the cell at (x, y). AsciiPlane has no colors, so they are
+// left to their default.
+func (this *AsciiPlane) At(x, y int) Cell {
+	return Cell{Rune: this.Chars[y*this.Stride+x]}
+}
+
+// At returns the cell at (x, y).
+func (this *AsciiColorPlane) At(x, y int) Cell {
+	return this.Cells[y*this.Stride+x]
+}
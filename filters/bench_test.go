@@ -0,0 +1,285 @@
+package filters_test
+
+// The benchmarks of this file produce the performance tables of README.md:
+//
+//	go test ./filters -run '^$' -bench . -readme
+//
+// prints them as markdown once every benchmark has run.
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+)
+
+var readme = flag.Bool("readme", false, "print the benchmark results as the README.md tables")
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if *readme {
+		printTables()
+	}
+
+	os.Exit(code)
+}
+
+var images = []struct{ name, path string }{
+	{"uwu", "../example_images/test_uwu.png"},
+	{"parrot", "../example_images/test_parrot.jpg"},
+	{"circle", "../example_images/test_circle.jpg"},
+}
+
+func load(tb testing.TB, path string) *filters.RGBAPlane {
+	tb.Helper()
+
+	img, err := io.Read(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return img
+}
+
+// maxError returns the largest absolute difference between two sample slices.
+func maxError[T float32 | uint8](want []float64, got []T) float64 {
+	var out float64
+	for i := range want {
+		out = max(out, math.Abs(want[i]-float64(got[i])))
+	}
+
+	return out
+}
+
+// table is a README table filled by the benchmarks.
+type table struct {
+	title, corner string
+	rows, columns []string
+	cells         map[[2]string]string
+}
+
+var tables struct {
+	sync.Mutex
+	list []*table
+}
+
+// record sets a cell of the README table with the given title, creating the
+// table, its row and its column as needed. Rows and columns keep the order in
+// which they were first recorded.
+func record(title, corner, row, column, value string) {
+	tables.Lock()
+	defer tables.Unlock()
+
+	var t *table
+	for _, candidate := range tables.list {
+		if candidate.title == title {
+			t = candidate
+		}
+	}
+
+	if t == nil {
+		t = &table{title: title, corner: corner, cells: map[[2]string]string{}}
+		tables.list = append(tables.list, t)
+	}
+
+	if _, ok := t.cells[[2]string{row, ""}]; !ok {
+		t.cells[[2]string{row, ""}] = row
+		t.rows = append(t.rows, row)
+	}
+
+	if _, ok := t.cells[[2]string{"", column}]; !ok {
+		t.cells[[2]string{"", column}] = column
+		t.columns = append(t.columns, column)
+	}
+
+	t.cells[[2]string{row, column}] = value
+}
+
+func printTables() {
+	tables.Lock()
+	defer tables.Unlock()
+
+	for _, t := range tables.list {
+		cells := make([][]string, len(t.rows)+1)
+		cells[0] = append([]string{t.corner}, t.columns...)
+		for i, row := range t.rows {
+			cells[i+1] = []string{row}
+			for _, column := range t.columns {
+				cells[i+1] = append(cells[i+1], t.cells[[2]string{row, column}])
+			}
+		}
+
+		widths := make([]int, len(cells[0]))
+		for _, line := range cells {
+			for i, cell := range line {
+				widths[i] = max(widths[i], len(cell))
+			}
+		}
+
+		fmt.Printf("\n%s\n\n", t.title)
+		for i, line := range cells {
+			for j, cell := range line {
+				fmt.Printf("|%-*s", widths[j], cell)
+			}
+			fmt.Println("|")
+
+			if i == 0 {
+				for _, width := range widths {
+					fmt.Print("|" + strings.Repeat("-", width))
+				}
+				fmt.Println("|")
+			}
+		}
+	}
+}
+
+// perOp returns the time taken by one iteration of b, times n.
+func perOp(b *testing.B, n int) string {
+	return (b.Elapsed() * time.Duration(n) / time.Duration(b.N)).Round(time.Microsecond).String()
+}
+
+// withError formats a timing followed by the precision lost against float64.
+func withError(timing string, maxErr float64) string {
+	return fmt.Sprintf("%s (%.3g)", timing, maxErr)
+}
+
+func BenchmarkLanczos(b *testing.B) {
+	for _, image := range images {
+		img := load(b, image.path)
+		width, height := img.Width/2, img.Height/4
+		want, _ := img.LanczosResize(width, height, 3)
+
+		b.Run(image.name+"/float64", func(b *testing.B) {
+			for b.Loop() {
+				img.LanczosResize(width, height, 3)
+			}
+			record("## Lanczos 10x", "commit", "HEAD", image.name, perOp(b, 10))
+			record("### Lanczos", "format", "float64", image.name, withError(perOp(b, 1), 0))
+		})
+
+		f32 := img.ToFloat32()
+		b.Run(image.name+"/float32", func(b *testing.B) {
+			var got *filters.RGBA32Plane
+			for b.Loop() {
+				got, _ = f32.LanczosResize(width, height, 3)
+			}
+			b.ReportMetric(maxError(want.RGBA, got.RGBA), "maxerr")
+			record("### Lanczos", "format", "float32", image.name, withError(perOp(b, 1), maxError(want.RGBA, got.RGBA)))
+		})
+
+		u8 := img.ToUint8()
+		b.Run(image.name+"/uint8", func(b *testing.B) {
+			var got *filters.RGBA8Plane
+			for b.Loop() {
+				got, _ = u8.LanczosResize(width, height, 3)
+			}
+			b.ReportMetric(maxError(want.RGBA, got.RGBA), "maxerr")
+			record("### Lanczos", "format", "uint8", image.name, withError(perOp(b, 1), maxError(want.RGBA, got.RGBA)))
+		})
+	}
+}
+
+func BenchmarkSobel(b *testing.B) {
+	for _, image := range images {
+		gray := load(b, image.path).ToGrayScale()
+		want := gray.SobelEdgeDetection()
+
+		// magnitudeError compares gradient magnitudes only: angles are
+		// unstable where the magnitude is close to zero.
+		magnitudeError := func(got *filters.EdgePlane) float64 {
+			var out float64
+			for i := 0; i < len(want.Gradient); i += 2 {
+				out = max(out, math.Abs(want.Gradient[i]-got.Gradient[i]))
+			}
+			return out
+		}
+
+		b.Run(image.name+"/float64", func(b *testing.B) {
+			for b.Loop() {
+				gray.SobelEdgeDetection()
+			}
+			record("## Sobel", "commit", "HEAD", image.name, perOp(b, 1))
+			record("### Sobel", "format", "float64", image.name, withError(perOp(b, 1), 0))
+		})
+
+		f32 := gray.ToFloat32()
+		b.Run(image.name+"/float32", func(b *testing.B) {
+			var got *filters.EdgePlane
+			for b.Loop() {
+				got = f32.SobelEdgeDetection()
+			}
+			b.ReportMetric(magnitudeError(got), "maxerr")
+			record("### Sobel", "format", "float32", image.name, withError(perOp(b, 1), magnitudeError(got)))
+		})
+
+		u8 := gray.ToUint8()
+		b.Run(image.name+"/uint8", func(b *testing.B) {
+			var got *filters.EdgePlane
+			for b.Loop() {
+				got = u8.SobelEdgeDetection()
+			}
+			b.ReportMetric(magnitudeError(got), "maxerr")
+			record("### Sobel", "format", "uint8", image.name, withError(perOp(b, 1), magnitudeError(got)))
+		})
+	}
+}
+
+// perCell encodes plane the way Colorize used to, with a full escape sequence
+// before every character.
+func perCell(plane *filters.AsciiColorPlane) []string {
+	out := make([]string, plane.Height)
+	for y := range plane.Height {
+		var line strings.Builder
+		for _, cell := range plane.Cells[y*plane.Stride : y*plane.Stride+plane.Width] {
+			fmt.Fprintf(&line, "\x1B[38;2;%d;%d;%dm%c", cell.FG.R, cell.FG.G, cell.FG.B, cell.Rune)
+		}
+		out[y] = line.String()
+	}
+
+	return out
+}
+
+func BenchmarkANSI(b *testing.B) {
+	encoders := []struct {
+		name   string
+		encode func(plane *filters.AsciiColorPlane) []string
+	}{
+		{"per cell", perCell},
+		{"on change", filters.Encoder{}.EncodePlane},
+		{"tolerance 8", filters.Encoder{Tolerance: 8}.EncodePlane},
+		{"256 colors", filters.Encoder{Profile: filters.ANSI256}.EncodePlane},
+	}
+
+	for _, image := range images {
+		img := load(b, image.path)
+
+		// A 200 columns render, as Braille cells of 2x4 pixels.
+		gray, _ := img.LanczosResize(400, 400*img.Height/img.Width/2*2, 3)
+		colors, _ := img.LanczosResize(200, gray.Height/4, 3)
+		plane, _ := gray.ToGrayScale().Braille(128).Colorize(colors)
+
+		for _, encoder := range encoders {
+			b.Run(image.name+"/"+encoder.name, func(b *testing.B) {
+				var lines []string
+				for b.Loop() {
+					lines = encoder.encode(plane)
+				}
+
+				bytes := 0
+				for _, line := range lines {
+					bytes += len(line) + 1
+				}
+				b.ReportMetric(float64(bytes), "bytes")
+				record("## ANSI output", "encoder", encoder.name, image.name, fmt.Sprintf("%dKB (%s)", bytes/1000, perOp(b, 1)))
+			})
+		}
+	}
+}
@@ -0,0 +1,584 @@
+package filters
+
+import (
+	"context"
+	"errors"
+)
+
+// Compact planes trade the precision of the float64 planes for memory.
+//
+// A float64 RGBA pixel takes 32 bytes, which puts a 4K frame above 250 MB
+// before any filter runs. The float32 planes halve that and the uint8 planes
+// bring it down to 4 bytes per pixel, at the cost of rounding every
+// intermediate result to the nearest integer.
+//
+// Compact planes expose the same filters as their float64 counterparts and
+// return planes of the same format, so a pipeline stays compact from end to
+// end. Use the ToUint8, ToFloat32 and ToFloat64 methods to convert at the
+// boundaries, for example before calling Colorize which expects an RGBAPlane.
+
+// RGBA8Plane is the uint8 counterpart of RGBAPlane.
+//
+// The pixel at coordinates (x, y) starts at index:
+//
+//	(y * Stride) + (x * 4)
+type RGBA8Plane struct {
+	// RGBA holds the image's pixel data in R, G, B, A order.
+	RGBA []uint8
+
+	// Width and Height define the dimensions of the image in pixels.
+	Width, Height int
+
+	// Stride is the number of uint8 values between the start of two
+	// vertically adjacent pixels. For tightly packed images, this is Width * 4.
+	Stride int
+}
+
+// NewRGBA8Plane allocates and returns a new tightly packed RGBA8Plane with
+// the given dimensions.
+func NewRGBA8Plane(width, height int) *RGBA8Plane {
+	return &RGBA8Plane{
+		RGBA:   make([]uint8, height*width*4),
+		Width:  width,
+		Height: height,
+		Stride: width * 4,
+	}
+}
+
+func (img *RGBA8Plane) view() plane[uint8] {
+	return plane[uint8]{img.RGBA, img.Width, img.Height, img.Stride}
+}
+
+// RGBA32Plane is the float32 counterpart of RGBAPlane.
+//
+// The pixel at coordinates (x, y) starts at index:
+//
+//	(y * Stride) + (x * 4)
+type RGBA32Plane struct {
+	// RGBA holds the image's pixel data in R, G, B, A order.
+	RGBA []float32
+
+	// Width and Height define the dimensions of the image in pixels.
+	Width, Height int
+
+	// Stride is the number of float32 values between the start of two
+	// vertically adjacent pixels. For tightly packed images, this is Width * 4.
+	Stride int
+}
+
+// NewRGBA32Plane allocates and returns a new tightly packed RGBA32Plane with
+// the given dimensions.
+func NewRGBA32Plane(width, height int) *RGBA32Plane {
+	return &RGBA32Plane{
+		RGBA:   make([]float32, height*width*4),
+		Width:  width,
+		Height: height,
+		Stride: width * 4,
+	}
+}
+
+func (img *RGBA32Plane) view() plane[float32] {
+	return plane[float32]{img.RGBA, img.Width, img.Height, img.Stride}
+}
+
+// GrayScale8Plane is the uint8 counterpart of GrayScalePlane.
+//
+// The pixel at coordinates (x, y) is at index:
+//
+//	(y * Stride) + (x)
+type GrayScale8Plane struct {
+	// Shades holds the grayscale intensity values for each pixel.
+	Shades []uint8
+
+	// Width and Height define the dimensions of the image in pixels.
+	Width, Height int
+
+	// Stride is the number of uint8 values between vertically adjacent pixels.
+	Stride int
+}
+
+// NewGrayScale8Plane allocates and returns a new tightly packed
+// GrayScale8Plane with the given dimensions.
+func NewGrayScale8Plane(width, height int) *GrayScale8Plane {
+	return &GrayScale8Plane{
+		Shades: make([]uint8, width*height),
+		Width:  width,
+		Height: height,
+		Stride: width,
+	}
+}
+
+func (img *GrayScale8Plane) view() plane[uint8] {
+	return plane[uint8]{img.Shades, img.Width, img.Height, img.Stride}
+}
+
+// GrayScale32Plane is the float32 counterpart of GrayScalePlane.
+//
+// The pixel at coordinates (x, y) is at index:
+//
+//	(y * Stride) + (x)
+type GrayScale32Plane struct {
+	// Shades holds the grayscale intensity values for each pixel.
+	Shades []float32
+
+	// Width and Height define the dimensions of the image in pixels.
+	Width, Height int
+
+	// Stride is the number of float32 values between vertically adjacent pixels.
+	Stride int
+}
+
+// NewGrayScale32Plane allocates and returns a new tightly packed
+// GrayScale32Plane with the given dimensions.
+func NewGrayScale32Plane(width, height int) *GrayScale32Plane {
+	return &GrayScale32Plane{
+		Shades: make([]float32, width*height),
+		Width:  width,
+		Height: height,
+		Stride: width,
+	}
+}
+
+func (img *GrayScale32Plane) view() plane[float32] {
+	return plane[float32]{img.Shades, img.Width, img.Height, img.Stride}
+}
+
+// Format conversions.
+//
+// Narrowing conversions clamp every sample to [0, 255]; conversions to uint8
+// round to the nearest integer.
+
+// ToUint8 returns a copy of the image stored as an RGBA8Plane.
+func (img *RGBAPlane) ToUint8() *RGBA8Plane {
+	out := NewRGBA8Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 4)
+	return out
+}
+
+// ToFloat32 returns a copy of the image stored as an RGBA32Plane.
+func (img *RGBAPlane) ToFloat32() *RGBA32Plane {
+	out := NewRGBA32Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 4)
+	return out
+}
+
+// ToFloat64 returns a copy of the image stored as an RGBAPlane.
+func (img *RGBA8Plane) ToFloat64() *RGBAPlane {
+	out := NewRGBAPlane(img.Width, img.Height)
+	convert(img.view(), out.view(), 4)
+	return out
+}
+
+// ToFloat32 returns a copy of the image stored as an RGBA32Plane.
+func (img *RGBA8Plane) ToFloat32() *RGBA32Plane {
+	out := NewRGBA32Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 4)
+	return out
+}
+
+// ToFloat64 returns a copy of the image stored as an RGBAPlane.
+func (img *RGBA32Plane) ToFloat64() *RGBAPlane {
+	out := NewRGBAPlane(img.Width, img.Height)
+	convert(img.view(), out.view(), 4)
+	return out
+}
+
+// ToUint8 returns a copy of the image stored as an RGBA8Plane.
+func (img *RGBA32Plane) ToUint8() *RGBA8Plane {
+	out := NewRGBA8Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 4)
+	return out
+}
+
+// ToUint8 returns a copy of the image stored as a GrayScale8Plane.
+func (img *GrayScalePlane) ToUint8() *GrayScale8Plane {
+	out := NewGrayScale8Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 1)
+	return out
+}
+
+// ToFloat32 returns a copy of the image stored as a GrayScale32Plane.
+func (img *GrayScalePlane) ToFloat32() *GrayScale32Plane {
+	out := NewGrayScale32Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 1)
+	return out
+}
+
+// ToFloat64 returns a copy of the image stored as a GrayScalePlane.
+func (img *GrayScale8Plane) ToFloat64() *GrayScalePlane {
+	out := NewGrayScalePlane(img.Width, img.Height)
+	convert(img.view(), out.view(), 1)
+	return out
+}
+
+// ToFloat32 returns a copy of the image stored as a GrayScale32Plane.
+func (img *GrayScale8Plane) ToFloat32() *GrayScale32Plane {
+	out := NewGrayScale32Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 1)
+	return out
+}
+
+// ToFloat64 returns a copy of the image stored as a GrayScalePlane.
+func (img *GrayScale32Plane) ToFloat64() *GrayScalePlane {
+	out := NewGrayScalePlane(img.Width, img.Height)
+	convert(img.view(), out.view(), 1)
+	return out
+}
+
+// ToUint8 returns a copy of the image stored as a GrayScale8Plane.
+func (img *GrayScale32Plane) ToUint8() *GrayScale8Plane {
+	out := NewGrayScale8Plane(img.Width, img.Height)
+	convert(img.view(), out.view(), 1)
+	return out
+}
+
+// Filters.
+//
+// Every method below behaves like the RGBAPlane or GrayScalePlane method of
+// the same name; see their documentation for details.
+
+// ToGrayScale converts the image to grayscale using the Rec. 709 luminance
+// formula. See RGBAPlane.ToGrayScale.
+func (img *RGBA8Plane) ToGrayScale() *GrayScale8Plane {
+	out := NewGrayScale8Plane(img.Width, img.Height)
+	toGrayScale(img.view(), out.view())
+	return out
+}
+
+// ToGrayScaleInto writes the grayscale version of the image into dst.
+// See RGBAPlane.ToGrayScaleInto.
+func (img *RGBA8Plane) ToGrayScaleInto(dst *GrayScale8Plane) error {
+	if err := checkSize("ToGrayScaleInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	toGrayScale(img.view(), dst.view())
+	return nil
+}
+
+// ToGrayScale converts the image to grayscale using the Rec. 709 luminance
+// formula. See RGBAPlane.ToGrayScale.
+func (img *RGBA32Plane) ToGrayScale() *GrayScale32Plane {
+	out := NewGrayScale32Plane(img.Width, img.Height)
+	toGrayScale(img.view(), out.view())
+	return out
+}
+
+// ToGrayScaleInto writes the grayscale version of the image into dst.
+// See RGBAPlane.ToGrayScaleInto.
+func (img *RGBA32Plane) ToGrayScaleInto(dst *GrayScale32Plane) error {
+	if err := checkSize("ToGrayScaleInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	toGrayScale(img.view(), dst.view())
+	return nil
+}
+
+// ToRGBA replicates each shade across the red, green and blue channels.
+// See GrayScalePlane.ToRGBA.
+func (img *GrayScale8Plane) ToRGBA() *RGBA8Plane {
+	out := NewRGBA8Plane(img.Width, img.Height)
+	grayToRGBA(img.view(), out.view())
+	return out
+}
+
+// ToRGBA replicates each shade across the red, green and blue channels.
+// See GrayScalePlane.ToRGBA.
+func (img *GrayScale32Plane) ToRGBA() *RGBA32Plane {
+	out := NewRGBA32Plane(img.Width, img.Height)
+	grayToRGBA(img.view(), out.view())
+	return out
+}
+
+// Inverse returns the photographic negative of the image, preserving alpha.
+// See RGBAPlane.Inverse.
+func (img *RGBA8Plane) Inverse() *RGBA8Plane {
+	out := NewRGBA8Plane(img.Width, img.Height)
+	inverseRGBA(context.Background(), img.view(), out.view())
+	return out
+}
+
+// InverseInto writes the negative of the image into dst.
+// See RGBAPlane.InverseInto.
+func (img *RGBA8Plane) InverseInto(dst *RGBA8Plane) error {
+	if err := checkSize("InverseInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	return inverseRGBA(context.Background(), img.view(), dst.view())
+}
+
+// Inverse returns the photographic negative of the image, preserving alpha.
+// See RGBAPlane.Inverse.
+func (img *RGBA32Plane) Inverse() *RGBA32Plane {
+	out := NewRGBA32Plane(img.Width, img.Height)
+	inverseRGBA(context.Background(), img.view(), out.view())
+	return out
+}
+
+// InverseInto writes the negative of the image into dst.
+// See RGBAPlane.InverseInto.
+func (img *RGBA32Plane) InverseInto(dst *RGBA32Plane) error {
+	if err := checkSize("InverseInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	return inverseRGBA(context.Background(), img.view(), dst.view())
+}
+
+// Inverse returns the photographic negative of the image.
+// See GrayScalePlane.Inverse.
+func (img *GrayScale8Plane) Inverse() *GrayScale8Plane {
+	out := NewGrayScale8Plane(img.Width, img.Height)
+	inverseGray(context.Background(), img.view(), out.view())
+	return out
+}
+
+// InverseInto writes the negative of the image into dst.
+// See RGBAPlane.InverseInto.
+func (img *GrayScale8Plane) InverseInto(dst *GrayScale8Plane) error {
+	if err := checkSize("InverseInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	return inverseGray(context.Background(), img.view(), dst.view())
+}
+
+// Inverse returns the photographic negative of the image.
+// See GrayScalePlane.Inverse.
+func (img *GrayScale32Plane) Inverse() *GrayScale32Plane {
+	out := NewGrayScale32Plane(img.Width, img.Height)
+	inverseGray(context.Background(), img.view(), out.view())
+	return out
+}
+
+// InverseInto writes the negative of the image into dst.
+// See RGBAPlane.InverseInto.
+func (img *GrayScale32Plane) InverseInto(dst *GrayScale32Plane) error {
+	if err := checkSize("InverseInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	return inverseGray(context.Background(), img.view(), dst.view())
+}
+
+// LanczosResize resizes the image using Lanczos resampling with window size a.
+// See RGBAPlane.LanczosResize.
+func (img *RGBA8Plane) LanczosResize(width, height, a int) (*RGBA8Plane, error) {
+	if err := checkLanczos(width, height, a); err != nil {
+		return nil, err
+	}
+
+	out := NewRGBA8Plane(width, height)
+	tmp := scratch.RGBA8(width, img.Height)
+	lanczosRGBA(context.Background(), img.view(), tmp.view(), out.view(), a)
+	scratch.PutRGBA8(tmp)
+
+	return out, nil
+}
+
+// LanczosResizeInto resizes the image to the dimensions of dst.
+// See RGBAPlane.LanczosResizeInto.
+func (img *RGBA8Plane) LanczosResizeInto(dst *RGBA8Plane, a int) error {
+	if err := checkLanczos(dst.Width, dst.Height, a); err != nil {
+		return err
+	}
+
+	tmp := scratch.RGBA8(dst.Width, img.Height)
+	lanczosRGBA(context.Background(), img.view(), tmp.view(), dst.view(), a)
+	scratch.PutRGBA8(tmp)
+
+	return nil
+}
+
+// LanczosResize resizes the image using Lanczos resampling with window size a.
+// See RGBAPlane.LanczosResize.
+func (img *RGBA32Plane) LanczosResize(width, height, a int) (*RGBA32Plane, error) {
+	if err := checkLanczos(width, height, a); err != nil {
+		return nil, err
+	}
+
+	out := NewRGBA32Plane(width, height)
+	tmp := scratch.RGBA32(width, img.Height)
+	lanczosRGBA(context.Background(), img.view(), tmp.view(), out.view(), a)
+	scratch.PutRGBA32(tmp)
+
+	return out, nil
+}
+
+// LanczosResizeInto resizes the image to the dimensions of dst.
+// See RGBAPlane.LanczosResizeInto.
+func (img *RGBA32Plane) LanczosResizeInto(dst *RGBA32Plane, a int) error {
+	if err := checkLanczos(dst.Width, dst.Height, a); err != nil {
+		return err
+	}
+
+	tmp := scratch.RGBA32(dst.Width, img.Height)
+	lanczosRGBA(context.Background(), img.view(), tmp.view(), dst.view(), a)
+	scratch.PutRGBA32(tmp)
+
+	return nil
+}
+
+// LanczosResize resizes the image using Lanczos resampling with window size a.
+// See GrayScalePlane.LanczosResize.
+func (img *GrayScale8Plane) LanczosResize(width, height, a int) (*GrayScale8Plane, error) {
+	if err := checkLanczos(width, height, a); err != nil {
+		return nil, err
+	}
+
+	out := NewGrayScale8Plane(width, height)
+	tmp := scratch.GrayScale8(width, img.Height)
+	lanczosGray(context.Background(), img.view(), tmp.view(), out.view(), a)
+	scratch.PutGrayScale8(tmp)
+
+	return out, nil
+}
+
+// LanczosResizeInto resizes the image to the dimensions of dst.
+// See RGBAPlane.LanczosResizeInto.
+func (img *GrayScale8Plane) LanczosResizeInto(dst *GrayScale8Plane, a int) error {
+	if err := checkLanczos(dst.Width, dst.Height, a); err != nil {
+		return err
+	}
+
+	tmp := scratch.GrayScale8(dst.Width, img.Height)
+	lanczosGray(context.Background(), img.view(), tmp.view(), dst.view(), a)
+	scratch.PutGrayScale8(tmp)
+
+	return nil
+}
+
+// LanczosResize resizes the image using Lanczos resampling with window size a.
+// See GrayScalePlane.LanczosResize.
+func (img *GrayScale32Plane) LanczosResize(width, height, a int) (*GrayScale32Plane, error) {
+	if err := checkLanczos(width, height, a); err != nil {
+		return nil, err
+	}
+
+	out := NewGrayScale32Plane(width, height)
+	tmp := scratch.GrayScale32(width, img.Height)
+	lanczosGray(context.Background(), img.view(), tmp.view(), out.view(), a)
+	scratch.PutGrayScale32(tmp)
+
+	return out, nil
+}
+
+// LanczosResizeInto resizes the image to the dimensions of dst.
+// See RGBAPlane.LanczosResizeInto.
+func (img *GrayScale32Plane) LanczosResizeInto(dst *GrayScale32Plane, a int) error {
+	if err := checkLanczos(dst.Width, dst.Height, a); err != nil {
+		return err
+	}
+
+	tmp := scratch.GrayScale32(dst.Width, img.Height)
+	lanczosGray(context.Background(), img.view(), tmp.view(), dst.view(), a)
+	scratch.PutGrayScale32(tmp)
+
+	return nil
+}
+
+// BayerDithering applies ordered Bayer dithering with a 2^n × 2^n matrix.
+// See GrayScalePlane.BayerDithering.
+func (img *GrayScale8Plane) BayerDithering(n int) (*GrayScale8Plane, error) {
+	if n < 1 || n > 8 {
+		return nil, errors.New("BayerDithering: n must be between 1 and 8")
+	}
+
+	out := NewGrayScale8Plane(img.Width, img.Height)
+	bayer(context.Background(), img.view(), out.view(), n)
+
+	return out, nil
+}
+
+// BayerDitheringInto writes the dithered image into dst.
+// See GrayScalePlane.BayerDitheringInto.
+func (img *GrayScale8Plane) BayerDitheringInto(dst *GrayScale8Plane, n int) error {
+	if n < 1 || n > 8 {
+		return errors.New("BayerDithering: n must be between 1 and 8")
+	}
+
+	if err := checkSize("BayerDitheringInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	bayer(context.Background(), img.view(), dst.view(), n)
+	return nil
+}
+
+// BayerDithering applies ordered Bayer dithering with a 2^n × 2^n matrix.
+// See GrayScalePlane.BayerDithering.
+func (img *GrayScale32Plane) BayerDithering(n int) (*GrayScale32Plane, error) {
+	if n < 1 || n > 8 {
+		return nil, errors.New("BayerDithering: n must be between 1 and 8")
+	}
+
+	out := NewGrayScale32Plane(img.Width, img.Height)
+	bayer(context.Background(), img.view(), out.view(), n)
+
+	return out, nil
+}
+
+// BayerDitheringInto writes the dithered image into dst.
+// See GrayScalePlane.BayerDitheringInto.
+func (img *GrayScale32Plane) BayerDitheringInto(dst *GrayScale32Plane, n int) error {
+	if n < 1 || n > 8 {
+		return errors.New("BayerDithering: n must be between 1 and 8")
+	}
+
+	if err := checkSize("BayerDitheringInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	bayer(context.Background(), img.view(), dst.view(), n)
+	return nil
+}
+
+// SobelEdgeDetection computes the gradient magnitude and orientation of each
+// pixel. The result is always a float64 EdgePlane.
+// See GrayScalePlane.SobelEdgeDetection.
+func (img *GrayScale8Plane) SobelEdgeDetection() *EdgePlane {
+	out := NewEdgePlane(img.Width, img.Height)
+	sobel(context.Background(), img.view(), out)
+	return out
+}
+
+// SobelEdgeDetection computes the gradient magnitude and orientation of each
+// pixel. The result is always a float64 EdgePlane.
+// See GrayScalePlane.SobelEdgeDetection.
+func (img *GrayScale32Plane) SobelEdgeDetection() *EdgePlane {
+	out := NewEdgePlane(img.Width, img.Height)
+	sobel(context.Background(), img.view(), out)
+	return out
+}
+
+// Ascii maps each pixel to a character of palette. See GrayScalePlane.Ascii.
+func (img *GrayScale8Plane) Ascii(palette []rune) *AsciiPlane {
+	out := NewAsciiPlane(img.Width, img.Height)
+	ascii(img.view(), out, palette)
+	return out
+}
+
+// Ascii maps each pixel to a character of palette. See GrayScalePlane.Ascii.
+func (img *GrayScale32Plane) Ascii(palette []rune) *AsciiPlane {
+	out := NewAsciiPlane(img.Width, img.Height)
+	ascii(img.view(), out, palette)
+	return out
+}
+
+// Braille maps each 2×4 block of pixels to a Braille character.
+// See GrayScalePlane.Braille.
+func (img *GrayScale8Plane) Braille(threshold float64) *AsciiPlane {
+	out := NewAsciiPlane(img.Width/2, img.Height/4)
+	braille(img.view(), out, threshold)
+	return out
+}
+
+// Braille maps each 2×4 block of pixels to a Braille character.
+// See GrayScalePlane.Braille.
+func (img *GrayScale32Plane) Braille(threshold float64) *AsciiPlane {
+	out := NewAsciiPlane(img.Width/2, img.Height/4)
+	braille(img.view(), out, threshold)
+	return out
+}
@@ -0,0 +1,15 @@
+package filters_test
+
+import "testing"
+
+func TestCompactConversionRoundTrip(t *testing.T) {
+	img := load(t, images[2].path)
+
+	if err := maxError(img.RGBA, img.ToUint8().RGBA); err != 0 {
+		t.Errorf("uint8 round trip lost precision: max error %v", err)
+	}
+
+	if err := maxError(img.RGBA, img.ToFloat32().RGBA); err != 0 {
+		t.Errorf("float32 round trip lost precision: max error %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package filters
 
 import (
+	"context"
 	"math"
 )
 
@@ -12,23 +13,26 @@ import (
 // This operation does not perform any scaling or gamma correction; it is a
 // straightforward replication of the grayscale intensity into RGB channels.
 //
-// The computation is parallelized across rows for performance.
+// The computation is parallelized across tiles for performance.
 func (img *GrayScalePlane) ToRGBA() *RGBAPlane {
 	out := NewRGBAPlane(img.Width, img.Height)
+	grayToRGBA(img.view(), out.view())
+	return out
+}
 
-	split(img.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < img.Height; y++ {
-			for x := range img.Width {
-				index := y*out.Stride + x*4
-				out.RGBA[index] = img.Shades[y*img.Stride+x]
-				out.RGBA[index+1] = img.Shades[y*img.Stride+x]
-				out.RGBA[index+2] = img.Shades[y*img.Stride+x]
-				out.RGBA[index+3] = 0xff
+func grayToRGBA[T sample](src, dst plane[T]) {
+	parallel(context.Background(), src.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				shade := src.pix[y*src.stride+x]
+				index := y*dst.stride + x*4
+				dst.pix[index] = shade
+				dst.pix[index+1] = shade
+				dst.pix[index+2] = shade
+				dst.pix[index+3] = 0xff
 			}
 		}
-	}).Wait()
-
-	return out
+	})
 }
 
 const r = 0.2126
@@ -45,29 +49,90 @@ const b = 0.0722
 // The alpha channel is ignored. The result is clamped to [0, 255] to ensure
 // valid pixel intensity values.
 //
-// The computation is parallelized across rows for performance.
+// The computation is parallelized across tiles for performance.
 func (img *RGBAPlane) ToGrayScale() *GrayScalePlane {
 	out := NewGrayScalePlane(img.Width, img.Height)
+	toGrayScale(img.view(), out.view())
+	return out
+}
+
+// ToGrayScaleInto is like ToGrayScale but writes the result into dst instead
+// of allocating a new plane. dst must have the same dimensions as img.
+func (img *RGBAPlane) ToGrayScaleInto(dst *GrayScalePlane) error {
+	if err := checkSize("ToGrayScaleInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	toGrayScale(img.view(), dst.view())
+	return nil
+}
+
+func toGrayScale[T sample](src, dst plane[T]) {
+	if src8, ok := any(src).(plane[uint8]); ok {
+		toGrayScaleFixed(src8, any(dst).(plane[uint8]))
+		return
+	}
+
+	offset := bias[T]()
+
+	parallel(context.Background(), src.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				index := y*src.stride + x*4
+				shade := r*float64(src.pix[index]) + g*float64(src.pix[index+1]) + b*float64(src.pix[index+2])
+				dst.pix[y*dst.stride+x] = T(clamp(shade, 0, 255) + offset)
+			}
+		}
+	})
+}
+
+// Rec. 709 luminance weights in 16.16 fixed point. They sum to 1 << 16 so that
+// white stays white.
+const (
+	rFixed = 13933
+	gFixed = 46871
+	bFixed = 4732
+)
+
+// toGrayScaleFixed is toGrayScale for uint8 planes, in integer arithmetic.
+func toGrayScaleFixed(src, dst plane[uint8]) {
+	parallel(context.Background(), src.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := src.pix[y*src.stride+x0*4 : y*src.stride+x1*4]
+			out := dst.pix[y*dst.stride+x0 : y*dst.stride+x1]
 
-	split(img.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < img.Height; y++ {
-			for x := range img.Width {
-				index := y*img.Stride + x*4
-				shade := r*img.RGBA[index] + g*img.RGBA[index+1] + b*img.RGBA[index+2]
-				out.Shades[y*out.Stride+x] = clamp(shade, 0, 255)
+			for x := range out {
+				pix := row[x*4 : x*4+4]
+				out[x] = uint8((rFixed*uint32(pix[0]) + gFixed*uint32(pix[1]) + bFixed*uint32(pix[2]) + 1<<15) >> 16)
 			}
 		}
-	}).Wait()
+	})
+}
 
-	return out
+// convert copies every sample of src into dst, converting it to the
+// destination format. Both planes must have the same dimensions and hold the
+// given number of channels per pixel.
+func convert[S, D sample](src plane[S], dst plane[D], channels int) {
+	offset := bias[D]()
+
+	parallel(context.Background(), src.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			srcRow := src.pix[y*src.stride+x0*channels : y*src.stride+x1*channels]
+			dstRow := dst.pix[y*dst.stride+x0*channels : y*dst.stride+x1*channels]
+
+			for i, v := range srcRow {
+				dstRow[i] = D(clamp(float64(v), 0, 255) + offset)
+			}
+		}
+	})
 }
 
 func (img *EdgePlane) ToRGBA(threshold float64) *RGBAPlane {
 	out := NewRGBAPlane(img.Width, img.Height)
 
-	split(img.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < img.Height; y++ {
-			for x := range img.Width {
+	parallel(context.Background(), img.Width, img.Height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
 				srcIndex := y*img.Stride + x*2
 				outIndex := y*out.Stride + x*4
 
@@ -87,7 +152,7 @@ func (img *EdgePlane) ToRGBA(threshold float64) *RGBAPlane {
 				}
 			}
 		}
-	}).Wait()
+	})
 
 	return out
 }
@@ -1,8 +1,9 @@
 package filters
 
 import (
+	"context"
 	"errors"
-	"math"
+	"sync"
 )
 
 // m generates an n-bit Bayer threshold matrix used for ordered dithering.
@@ -44,6 +45,19 @@ func m(n int) [][]uint8 {
 	return out
 }
 
+// matrices caches the threshold maps generated by m, indexed by bit depth.
+var matrices sync.Map
+
+// matrix returns the cached n-bit Bayer threshold map, generating it on first use.
+func matrix(n int) [][]uint8 {
+	if M, ok := matrices.Load(n); ok {
+		return M.([][]uint8)
+	}
+
+	M, _ := matrices.LoadOrStore(n, m(n))
+	return M.([][]uint8)
+}
+
 // BayerDithering applies ordered Bayer dithering to a grayscale image.
 //
 // Each pixel in the output image is compared against a threshold from a
@@ -56,34 +70,62 @@ func m(n int) [][]uint8 {
 //
 // Returns:
 //   - A new GrayscalePlane containing the dithered image
-//   - An error if the bit depth n is less than 1
+//   - An error if the bit depth n is not between 1 and 8
 //
-// The computation is parallelized across rows for improved performance.
+// The computation is parallelized across tiles for improved performance.
 func (img *GrayScalePlane) BayerDithering(n int) (*GrayScalePlane, error) {
-	if n < 1 {
-		return nil, errors.New("BayerDithering: n must be >= 1")
+	return img.BayerDitheringContext(context.Background(), n)
+}
+
+// BayerDitheringContext is like BayerDithering but stops early and returns
+// ctx.Err() when ctx is cancelled.
+func (img *GrayScalePlane) BayerDitheringContext(ctx context.Context, n int) (*GrayScalePlane, error) {
+	if n < 1 || n > 8 {
+		return nil, errors.New("BayerDithering: n must be between 1 and 8")
 	}
 
 	// Allocate output image
 	out := NewGrayScalePlane(img.Width, img.Height)
+	if err := bayer(ctx, img.view(), out.view(), n); err != nil {
+		return nil, err
+	}
 
+	return out, nil
+}
+
+// BayerDitheringInto is like BayerDithering but writes the result into dst
+// instead of allocating a new plane. dst must have the same dimensions as img
+// and may be img itself to dither the image in place.
+func (img *GrayScalePlane) BayerDitheringInto(dst *GrayScalePlane, n int) error {
+	if n < 1 || n > 8 {
+		return errors.New("BayerDithering: n must be between 1 and 8")
+	}
+
+	if err := checkSize("BayerDitheringInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
+
+	return bayer(context.Background(), img.view(), dst.view(), n)
+}
+
+func bayer[T sample](ctx context.Context, src, dst plane[T], n int) error {
 	// Generate the Bayer threshold map
-	M := m(n)
-	norm := float64(int(1) << n)
+	M := matrix(n)
+	mask := 1<<n - 1
 
-	split(img.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < img.Height; y++ {
-			for x := range img.Width {
+	return parallel(ctx, src.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
 
-				lhs := uint8(img.Shades[y*img.Stride+x])
-				rhs := M[int(math.Mod(float64(y), norm))][int(math.Mod(float64(x), norm))]
+				lhs := uint8(src.pix[y*src.stride+x])
+				rhs := M[y&mask][x&mask]
 
 				if lhs > rhs {
-					out.Shades[y*out.Stride+x] = 255
+					dst.pix[y*dst.stride+x] = 255
+				} else {
+					dst.pix[y*dst.stride+x] = 0
 				}
 			}
 		}
-	}).Wait()
-
-	return out, nil
+	})
 }
@@ -1,55 +1,74 @@
 package filters
 
-// import (
-// 	"fmt"
-// 	"testing"
-// )
-//
-// func equal(_lhs, _rhs [][]float64) bool {
-// 	for y := range len(_lhs) {
-// 		for x := range len(_lhs[0]) {
-// 			if _lhs[y][x] != _rhs[y][x] {
-// 				return false
-// 			}
-// 		}
-// 	}
-//
-// 	return true
-// }
-//
-// func Test_m(t *testing.T) {
-// 	tests := []struct {
-// 		name string // description of this test case
-// 		// Named input parameters for target function.
-// 		_n   int
-// 		want [][]float64
-// 	}{
-// 		{
-// 			name: "M_2",
-// 			_n:   1,
-// 			want: [][]float64{
-// 				{0.0, 0.5},
-// 				{0.75, 0.25}},
-// 		},
-// 		{
-// 			name: "M_4",
-// 			_n:   2,
-// 			want: [][]float64{
-// 				{0.0, 0.5, 0.125, 0.625},
-// 				{0.75, 0.25, 0.875, 0.375},
-// 				{0.1875, 0.6875, 0.0625, 0.5625},
-// 				{0.9375, 0.4375, 0.8125, 0.3125}},
-// 		},
-// 	}
-// 	for _, tt := range tests {
-// 		t.Run(tt.name, func(t *testing.T) {
-// 			got := m(tt._n)
-//
-// 			fmt.Println(got)
-//
-// 			if !equal(tt.want, got) {
-// 				t.Errorf("m() = %v, want %v", got, tt.want)
-// 			}
-// 		})
-// 	}
-// }
+import (
+	"slices"
+	"testing"
+)
+
+func Test_m(t *testing.T) {
+	tests := []struct {
+		name string // description of this test case
+		// Named input parameters for target function.
+		_n   int
+		want [][]uint8
+	}{
+		{
+			name: "M_2",
+			_n:   1,
+			want: [][]uint8{
+				{0, 127},
+				{191, 63}},
+		},
+		{
+			name: "M_4",
+			_n:   2,
+			want: [][]uint8{
+				{0, 127, 31, 159},
+				{191, 63, 223, 95},
+				{47, 175, 15, 143},
+				{239, 111, 207, 79}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m(tt._n)
+
+			if !slices.EqualFunc(tt.want, got, slices.Equal) {
+				t.Errorf("m() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBayerDithering(t *testing.T) {
+	img := NewGrayScalePlane(4, 4)
+	for i := range img.Shades {
+		img.Shades[i] = 100
+	}
+
+	got, err := img.BayerDithering(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	M := m(2)
+	for y := range 4 {
+		for x := range 4 {
+			want := 0.
+			if 100 > float64(M[y][x]) {
+				want = 255
+			}
+
+			if v := got.Shades[y*got.Stride+x]; v != want {
+				t.Errorf("pixel (%d, %d) = %v, want %v", x, y, v, want)
+			}
+		}
+	}
+
+	if _, err := img.BayerDithering(0); err == nil {
+		t.Error("BayerDithering(0) returned no error")
+	}
+	if _, err := img.BayerDithering(64); err == nil {
+		t.Error("BayerDithering(64) returned no error")
+	}
+}
@@ -0,0 +1,258 @@
+package filters_test
+
+// The golden tests run every pipeline over every file of example_images and
+// compare the results against the files of testdata/golden. After an
+// intended change of output, regenerate them with:
+//
+//	go test ./filters -run Golden -update
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// goldenWidth is the width sources are resized to before going through the
+// pipelines, so that the golden files stay small.
+const goldenWidth = 96
+
+// pipeline turns a source image into either text or an image.
+type pipeline struct {
+	name string
+	text func(img *filters.RGBAPlane) ([]string, error)
+	png  func(img *filters.RGBAPlane) (*filters.RGBAPlane, error)
+}
+
+// resize resizes img to width pixels, keeping its aspect ratio once the
+// height is multiplied by ratio.
+func resize(img *filters.RGBAPlane, width int, ratio float64) (*filters.RGBAPlane, error) {
+	height := max(1, int(float64(img.Height*width)/float64(img.Width)*ratio))
+	return img.LanczosResize(width, height, 3)
+}
+
+var pipelines = []pipeline{
+	{
+		name: "ascii",
+		text: func(img *filters.RGBAPlane) ([]string, error) {
+			small, err := resize(img, goldenWidth/2, .5)
+			if err != nil {
+				return nil, err
+			}
+			return small.ToGrayScale().Ascii([]rune(" .:-=+*#%@")).Buffer(), nil
+		},
+	},
+	{
+		name: "braille",
+		text: func(img *filters.RGBAPlane) ([]string, error) {
+			small, err := resize(img, goldenWidth, 1)
+			if err != nil {
+				return nil, err
+			}
+			dithered, err := small.ToGrayScale().BayerDithering(2)
+			if err != nil {
+				return nil, err
+			}
+			return dithered.Braille(128).Buffer(), nil
+		},
+	},
+	{
+		name: "color",
+		text: func(img *filters.RGBAPlane) ([]string, error) {
+			small, err := resize(img, goldenWidth/2, .5)
+			if err != nil {
+				return nil, err
+			}
+			colored, err := small.ToGrayScale().Ascii([]rune(" .:-=+*#%@")).Colorize(small)
+			if err != nil {
+				return nil, err
+			}
+			return colored.Buffer(), nil
+		},
+	},
+	{
+		name: "edges",
+		text: func(img *filters.RGBAPlane) ([]string, error) {
+			small, err := resize(img, goldenWidth/2, .5)
+			if err != nil {
+				return nil, err
+			}
+			return small.ToGrayScale().SobelEdgeDetection().Ascii(200, []rune("|/-\\|/-\\|")).Buffer(), nil
+		},
+	},
+	{
+		name: "sobel",
+		png: func(img *filters.RGBAPlane) (*filters.RGBAPlane, error) {
+			small, err := resize(img, goldenWidth, 1)
+			if err != nil {
+				return nil, err
+			}
+			return small.ToGrayScale().SobelEdgeDetection().ToRGBA(100), nil
+		},
+	},
+	{
+		name: "dither",
+		png: func(img *filters.RGBAPlane) (*filters.RGBAPlane, error) {
+			small, err := resize(img, goldenWidth, 1)
+			if err != nil {
+				return nil, err
+			}
+			dithered, err := small.ToGrayScale().BayerDithering(3)
+			if err != nil {
+				return nil, err
+			}
+			return dithered.ToRGBA(), nil
+		},
+	},
+}
+
+// examples returns the files of example_images along with a name usable in
+// file paths and test names.
+func examples(tb testing.TB) [][2]string {
+	tb.Helper()
+
+	paths, err := filepath.Glob("../example_images/*")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	unsafe := regexp.MustCompile(`[^A-Za-z0-9_-]`)
+	out := make([][2]string, len(paths))
+	for i, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		out[i] = [2]string{unsafe.ReplaceAllString(name, "_"), path}
+	}
+
+	return out
+}
+
+func TestGolden(t *testing.T) {
+	for _, example := range examples(t) {
+		name, path := example[0], example[1]
+		img := load(t, path)
+
+		for _, p := range pipelines {
+			t.Run(name+"/"+p.name, func(t *testing.T) {
+				golden := filepath.Join("testdata", "golden", name, p.name)
+
+				if p.text != nil {
+					lines, err := p.text(img)
+					if err != nil {
+						t.Fatal(err)
+					}
+					compareText(t, golden+".txt", strings.Join(lines, "\n")+"\n")
+				} else {
+					out, err := p.png(img)
+					if err != nil {
+						t.Fatal(err)
+					}
+					compareImage(t, golden+".png", out)
+				}
+			})
+		}
+	}
+}
+
+func compareText(t *testing.T, path, got string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+
+	wantLines, gotLines := strings.Split(string(want), "\n"), strings.Split(got, "\n")
+	for i := range min(len(wantLines), len(gotLines)) {
+		if wantLines[i] != gotLines[i] {
+			t.Fatalf("%s: line %d differs\n got: %q\nwant: %q", path, i+1, gotLines[i], wantLines[i])
+		}
+	}
+	if len(wantLines) != len(gotLines) {
+		t.Fatalf("%s: %d lines, want %d", path, len(gotLines), len(wantLines))
+	}
+}
+
+func compareImage(t *testing.T, path string, got *filters.RGBAPlane) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := io.Write(path, got, io.WriteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := io.Read(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+
+	if want.Width != got.Width || want.Height != got.Height {
+		t.Fatalf("%s: image is %dx%d, want %dx%d", path, got.Width, got.Height, want.Width, want.Height)
+	}
+
+	// Written images are truncated to 8 bits, compare them as such.
+	for y := range got.Height {
+		for x := range got.Width * 4 {
+			w, g := want.RGBA[y*want.Stride+x], float64(uint8(got.RGBA[y*got.Stride+x]))
+			if w != g {
+				t.Fatalf("%s: sample %d of pixel (%d, %d) is %v, want %v", path, x%4, x/4, y, g, w)
+			}
+		}
+	}
+}
+
+func BenchmarkPipelines(b *testing.B) {
+	for _, example := range examples(b) {
+		name, path := example[0], example[1]
+		img := load(b, path)
+
+		for _, p := range pipelines {
+			b.Run(p.name+"/"+name, func(b *testing.B) {
+				for b.Loop() {
+					var err error
+					if p.text != nil {
+						_, err = p.text(img)
+					} else {
+						_, err = p.png(img)
+					}
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				record("## Pipelines", "pipeline", p.name, name, perOp(b, 1))
+			})
+		}
+	}
+}
+
+// TestGoldenNames checks that example names stay unique once sanitized.
+func TestGoldenNames(t *testing.T) {
+	seen := map[string]string{}
+	for _, example := range examples(t) {
+		if other, ok := seen[example[0]]; ok {
+			t.Errorf("%s and %s share the golden directory %s", other, example[1], example[0])
+		}
+		seen[example[0]] = example[1]
+	}
+}
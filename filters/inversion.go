@@ -1,5 +1,7 @@
 package filters
 
+import "context"
+
 // Inverse returns a new RGBAPlane where the color channels (R, G, B) of each
 // pixel are inverted, producing a photographic negative of the original image.
 //
@@ -9,40 +11,85 @@ package filters
 //
 //	newValue = 255 - oldValue
 //
-// This function is parallelized across rows to improve performance for large images.
+// This function is parallelized across tiles to improve performance for large images.
 func (img *RGBAPlane) Inverse() *RGBAPlane {
+	out, _ := img.InverseContext(context.Background())
+	return out
+}
+
+// InverseContext is like Inverse but stops early and returns ctx.Err() when
+// ctx is cancelled.
+func (img *RGBAPlane) InverseContext(ctx context.Context) (*RGBAPlane, error) {
 	out := NewRGBAPlane(img.Width, img.Height)
+	if err := inverseRGBA(ctx, img.view(), out.view()); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-	split(img.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < img.Height; y++ {
-			for x := range img.Width {
-				index := y*out.Stride + x*4
+// InverseInto is like Inverse but writes the result into dst instead of
+// allocating a new plane. dst must have the same dimensions as img and may be
+// img itself to invert the image in place.
+func (img *RGBAPlane) InverseInto(dst *RGBAPlane) error {
+	if err := checkSize("InverseInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
 
-				out.RGBA[index] = float64(^uint8(img.RGBA[index]))
-				out.RGBA[index+1] = float64(^uint8(img.RGBA[index+1]))
-				out.RGBA[index+2] = float64(^uint8(img.RGBA[index+2]))
-				// out.RGBA[index+3] = float64(^uint8(img.RGBA[index+3])) // dont inverse alpha channel
+	return inverseRGBA(context.Background(), img.view(), dst.view())
+}
+
+func inverseRGBA[T sample](ctx context.Context, src, dst plane[T]) error {
+	return parallel(ctx, src.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				srcIndex := y*src.stride + x*4
+				dstIndex := y*dst.stride + x*4
+
+				dst.pix[dstIndex] = T(^uint8(src.pix[srcIndex]))
+				dst.pix[dstIndex+1] = T(^uint8(src.pix[srcIndex+1]))
+				dst.pix[dstIndex+2] = T(^uint8(src.pix[srcIndex+2]))
+				dst.pix[dstIndex+3] = src.pix[srcIndex+3] // dont inverse alpha channel
 			}
 		}
-	}).Wait()
-
-	return out
+	})
 }
 
 // Inverse returns a new GrayScalePlane where the shade of each
 // pixel is inverted, producing a photographic negative of the original image.
 //
-// This function is parallelized across rows to improve performance for large images.
+// This function is parallelized across tiles to improve performance for large images.
 func (img *GrayScalePlane) Inverse() *GrayScalePlane {
+	out, _ := img.InverseContext(context.Background())
+	return out
+}
+
+// InverseContext is like Inverse but stops early and returns ctx.Err() when
+// ctx is cancelled.
+func (img *GrayScalePlane) InverseContext(ctx context.Context) (*GrayScalePlane, error) {
 	out := NewGrayScalePlane(img.Width, img.Height)
+	if err := inverseGray(ctx, img.view(), out.view()); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InverseInto is like Inverse but writes the result into dst instead of
+// allocating a new plane. dst must have the same dimensions as img and may be
+// img itself to invert the image in place.
+func (img *GrayScalePlane) InverseInto(dst *GrayScalePlane) error {
+	if err := checkSize("InverseInto", dst.Width, dst.Height, img.Width, img.Height); err != nil {
+		return err
+	}
 
-	split(img.Height, func(_start, _end int) {
-		for y := _start; y < _end && y < img.Height; y++ {
-			for x := range img.Width {
-				out.Shades[y*out.Stride+x] = float64(^uint8(img.Shades[y*img.Stride+x]))
+	return inverseGray(context.Background(), img.view(), dst.view())
+}
+
+func inverseGray[T sample](ctx context.Context, src, dst plane[T]) error {
+	return parallel(ctx, src.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				dst.pix[y*dst.stride+x] = T(^uint8(src.pix[y*src.stride+x]))
 			}
 		}
-	}).Wait()
-
-	return out
+	})
 }
@@ -1,6 +1,7 @@
 package filters
 
 import (
+	"context"
 	"errors"
 	"math"
 )
@@ -48,11 +49,11 @@ func l(x float64, a int) float64 {
 	return 0
 }
 
-func coeffs(ratio float64, a, dimension int) []float64 {
-	kern := make([]float64, dimension*2*a)
-
-	split(dimension, func(_start, _end int) {
-		for j := _start; j < _end && j < dimension; j++ {
+// coeffs fills kern, of length dimension*2*a, with the normalised Lanczos
+// weights of every output sample along one axis.
+func coeffs(kern []float64, ratio float64, a, dimension int) {
+	rows(context.Background(), dimension, func(_start, _end int) {
+		for j := _start; j < _end; j++ {
 			x := (float64(j)+0.5)*ratio - 0.5
 			sum := 0.
 
@@ -68,9 +69,74 @@ func coeffs(ratio float64, a, dimension int) []float64 {
 				kern[j*a*2+i] /= sum
 			}
 		}
-	}).Wait()
+	})
+}
+
+// fixedBits is the number of fractional bits of the fixed point weights used
+// to resample uint8 planes.
+const fixedBits = 14
+
+// taps describes the resampling of one axis. Output sample j reads the n
+// source samples index[j*n : j*n+n], already clamped to the source bounds,
+// and weighs them with weight (or fixed, its 2.14 fixed point version).
+//
+// Precomputing the clamped indices keeps floor, clamp and kernel evaluation
+// out of the inner loops, which are left with plain multiply-adds.
+type taps struct {
+	n      int
+	index  []int
+	weight []float64
+	fixed  []int32
+}
+
+// lanczosTaps returns the taps resampling src samples into dst samples with a
+// Lanczos window of size a. The taps come from the scratch pool; release them
+// with scratch.putKernel.
+func lanczosTaps(src, dst, a int) *taps {
+	t := scratch.kernel(dst, a)
+	ratio := float64(src) / float64(dst)
+	coeffs(t.weight, ratio, a, dst)
+
+	for j := range dst {
+		base := int(math.Floor((float64(j)+0.5)*ratio-0.5)) - (a - 1)
+		var sum int32
+
+		for i := range t.n {
+			t.index[j*t.n+i] = clamp(base+i, 0, src-1)
+			t.fixed[j*t.n+i] = int32(math.Round(t.weight[j*t.n+i] * (1 << fixedBits)))
+			sum += t.fixed[j*t.n+i]
+		}
+
+		// Hand the rounding error to the central tap so that the fixed point
+		// weights sum to one exactly and flat areas stay flat.
+		if t.n > 0 {
+			t.fixed[j*t.n+a-1] += 1<<fixedBits - sum
+		}
+	}
+
+	return t
+}
+
+// unfix rounds a 2.14 fixed point value to the nearest integer in [0, 255].
+func unfix(v int32) uint8 {
+	return uint8(clamp((v+1<<(fixedBits-1))>>fixedBits, 0, 255))
+}
+
+// checkLanczos validates the arguments shared by every LanczosResize variant.
+func checkLanczos(width, height, a int) error {
+	if width < 0 {
+		return errors.New("width must be positive")
+	}
+
+	if height < 0 {
+		return errors.New("height must be positive")
+	}
+
+	if a < 0 {
+		return errors.New("Lanczos window size must be positive")
+	}
 
-	return kern
+	return nil
 }
 
 // LanczosResize resizes the given RGBAPlane to the specified width and height using
@@ -86,89 +152,199 @@ func coeffs(ratio float64, a, dimension int) []float64 {
 //
 // An error is returned if the target dimensions or window size are invalid.
 func (img *RGBAPlane) LanczosResize(width, height, a int) (*RGBAPlane, error) {
+	return img.LanczosResizeContext(context.Background(), width, height, a)
+}
 
-	// Input sanitisation
-	if width < 0 {
-		return nil, errors.New("width must be positive")
+// LanczosResizeContext is like LanczosResize but stops early and returns
+// ctx.Err() when ctx is cancelled.
+func (img *RGBAPlane) LanczosResizeContext(ctx context.Context, width, height, a int) (*RGBAPlane, error) {
+	if err := checkLanczos(width, height, a); err != nil {
+		return nil, err
 	}
 
-	if height < 0 {
-		return nil, errors.New("height must be positive")
+	out := NewRGBAPlane(width, height)
+	tmp := scratch.RGBA(width, img.Height)
+	err := lanczosRGBA(ctx, img.view(), tmp.view(), out.view(), a)
+	scratch.PutRGBA(tmp)
+
+	if err != nil {
+		return nil, err
 	}
 
-	if a < 0 {
-		return nil, errors.New("Lanczos window size must be positive")
+	return out, nil
+}
+
+// LanczosResizeInto is like LanczosResize but resizes the image to the
+// dimensions of dst and writes the result into it instead of allocating a new
+// plane. dst must not share its storage with img.
+func (img *RGBAPlane) LanczosResizeInto(dst *RGBAPlane, a int) error {
+	if err := checkLanczos(dst.Width, dst.Height, a); err != nil {
+		return err
 	}
 
-	// Horizontal resampling
-	tmp := NewRGBAPlane(width, img.Height)
-	ratio := float64(img.Width) / float64(width)
-	kern := coeffs(ratio, a, width)
+	tmp := scratch.RGBA(dst.Width, img.Height)
+	lanczosRGBA(context.Background(), img.view(), tmp.view(), dst.view(), a)
+	scratch.PutRGBA(tmp)
 
-	split(img.Height, func(_start, _end int) {
-		for srcY := _start; srcY < _end && srcY < img.Height; srcY++ {
-			for x := range width {
+	return nil
+}
 
-				relX := (float64(x)+0.5)*ratio - 0.5
+// lanczosRGBA resamples src into dst, using tmp (dst.width × src.height) to
+// hold the result of the horizontal pass.
+//
+// uint8 planes are resampled with fixed point integer arithmetic, other
+// formats in float64.
+func lanczosRGBA[T sample](ctx context.Context, src, tmp, dst plane[T], a int) error {
+	h := lanczosTaps(src.width, dst.width, a)
+	defer scratch.putKernel(h)
+
+	var err error
+	if src8, ok := any(src).(plane[uint8]); ok {
+		err = lanczosFixedRGBA(ctx, src8, any(tmp).(plane[uint8]), h)
+	} else {
+		err = lanczosFloatRGBA(ctx, src, tmp, h)
+	}
+	if err != nil {
+		return err
+	}
+
+	v := lanczosTaps(src.height, dst.height, a)
+	defer scratch.putKernel(v)
+
+	return lanczosVertical(ctx, tmp, dst, v, 4)
+}
+
+// lanczosFloatRGBA is the horizontal pass of lanczosRGBA in float64.
+func lanczosFloatRGBA[T sample](ctx context.Context, src, tmp plane[T], h *taps) error {
+	offset := bias[T]()
+
+	return parallel(ctx, tmp.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := src.pix[y*src.stride : y*src.stride+src.width*4]
+			out := tmp.pix[y*tmp.stride : y*tmp.stride+tmp.width*4]
+
+			for x := x0; x < x1; x++ {
+				index := h.index[x*h.n : x*h.n+h.n]
+				weight := h.weight[x*h.n : x*h.n+h.n]
+				weight = weight[:len(index)]
 				var R, G, B, A float64
 
-				for i := range 2 * a {
-					srcX := int(math.Floor(relX) + float64(i) - (float64(a) - 1.))
-					srcX = clamp(srcX, 0, img.Width-1)
+				for i, srcX := range index {
+					pix := row[srcX*4 : srcX*4+4]
+					R += float64(pix[0]) * weight[i]
+					G += float64(pix[1]) * weight[i]
+					B += float64(pix[2]) * weight[i]
+					A += float64(pix[3]) * weight[i]
+				}
 
-					index := srcY*img.Stride + srcX*4
-					coeff := kern[x*a*2+i] // x === j
+				pix := out[x*4 : x*4+4]
+				pix[0] = T(clamp(R, 0, 255) + offset)
+				pix[1] = T(clamp(G, 0, 255) + offset)
+				pix[2] = T(clamp(B, 0, 255) + offset)
+				pix[3] = T(clamp(A, 0, 255) + offset)
+			}
+		}
+	})
+}
 
-					R += float64(img.RGBA[index]) * coeff
-					G += float64(img.RGBA[index+1]) * coeff
-					B += float64(img.RGBA[index+2]) * coeff
-					A += float64(img.RGBA[index+3]) * coeff
+// lanczosFixedRGBA is the horizontal pass of lanczosRGBA in fixed point.
+func lanczosFixedRGBA(ctx context.Context, src, tmp plane[uint8], h *taps) error {
+	return parallel(ctx, tmp.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := src.pix[y*src.stride : y*src.stride+src.width*4]
+			out := tmp.pix[y*tmp.stride : y*tmp.stride+tmp.width*4]
+
+			for x := x0; x < x1; x++ {
+				index := h.index[x*h.n : x*h.n+h.n]
+				weight := h.fixed[x*h.n : x*h.n+h.n]
+				weight = weight[:len(index)]
+				var R, G, B, A int32
+
+				for i, srcX := range index {
+					pix := row[srcX*4 : srcX*4+4]
+					R += int32(pix[0]) * weight[i]
+					G += int32(pix[1]) * weight[i]
+					B += int32(pix[2]) * weight[i]
+					A += int32(pix[3]) * weight[i]
 				}
 
-				index := srcY*tmp.Stride + x*4
-				tmp.RGBA[index] = clamp(R, 0, 255)
-				tmp.RGBA[index+1] = clamp(G, 0, 255)
-				tmp.RGBA[index+2] = clamp(B, 0, 255)
-				tmp.RGBA[index+3] = clamp(A, 0, 255)
+				pix := out[x*4 : x*4+4]
+				pix[0] = unfix(R)
+				pix[1] = unfix(G)
+				pix[2] = unfix(B)
+				pix[3] = unfix(A)
 			}
 		}
-	}).Wait()
+	})
+}
 
-	// Vertical resampling
-	out := NewRGBAPlane(width, height)
-	ratio = float64(img.Height) / float64(height)
-	kern = coeffs(ratio, a, height)
+// lanczosVertical is the vertical pass shared by every plane layout.
+//
+// Each output row is accumulated as a weighted sum of whole source rows, so
+// the inner loop walks contiguous memory regardless of the number of
+// channels. Rows are processed one tile at a time, which bounds the
+// accumulator to tileSize pixels.
+func lanczosVertical[T sample](ctx context.Context, tmp, dst plane[T], v *taps, channels int) error {
+	if tmp8, ok := any(tmp).(plane[uint8]); ok {
+		return lanczosFixedVertical(ctx, tmp8, any(dst).(plane[uint8]), v, channels)
+	}
 
-	split(width, func(_start, _end int) {
-		for srcX := _start; srcX < _end && srcX < width; srcX++ {
-			for y := range height {
+	offset := bias[T]()
 
-				relY := (float64(y)+0.5)*ratio - 0.5
-				var R, G, B, A float64
+	return parallel(ctx, dst.width, dst.height, func(x0, y0, x1, y1 int) {
+		var acc [tileSize * 4]float64
+		sum := acc[:(x1-x0)*channels]
 
-				for i := range 2 * a {
-					srcY := int(math.Floor(relY) + float64(i) - (float64(a) - 1.))
-					srcY = clamp(srcY, 0, tmp.Height-1)
+		for y := y0; y < y1; y++ {
+			clear(sum)
 
-					index := srcY*tmp.Stride + srcX*4
-					coeff := kern[y*a*2+i] // y === j
+			for i, srcY := range v.index[y*v.n : y*v.n+v.n] {
+				weight := v.weight[y*v.n+i]
+				row := tmp.pix[srcY*tmp.stride+x0*channels : srcY*tmp.stride+x1*channels]
+				row = row[:len(sum)]
 
-					R += float64(tmp.RGBA[index]) * coeff
-					G += float64(tmp.RGBA[index+1]) * coeff
-					B += float64(tmp.RGBA[index+2]) * coeff
-					A += float64(tmp.RGBA[index+3]) * coeff
+				for k, shade := range row {
+					sum[k] += float64(shade) * weight
 				}
+			}
 
-				index := y*tmp.Stride + srcX*4
-				out.RGBA[index] = clamp(R, 0, 255)
-				out.RGBA[index+1] = clamp(G, 0, 255)
-				out.RGBA[index+2] = clamp(B, 0, 255)
-				out.RGBA[index+3] = clamp(A, 0, 255)
+			out := dst.pix[y*dst.stride+x0*channels : y*dst.stride+x1*channels]
+			out = out[:len(sum)]
+
+			for k, shade := range sum {
+				out[k] = T(clamp(shade, 0, 255) + offset)
 			}
 		}
-	}).Wait()
+	})
+}
 
-	return out, nil
+// lanczosFixedVertical is lanczosVertical in fixed point.
+func lanczosFixedVertical(ctx context.Context, tmp, dst plane[uint8], v *taps, channels int) error {
+	return parallel(ctx, dst.width, dst.height, func(x0, y0, x1, y1 int) {
+		var acc [tileSize * 4]int32
+		sum := acc[:(x1-x0)*channels]
+
+		for y := y0; y < y1; y++ {
+			clear(sum)
+
+			for i, srcY := range v.index[y*v.n : y*v.n+v.n] {
+				weight := v.fixed[y*v.n+i]
+				row := tmp.pix[srcY*tmp.stride+x0*channels : srcY*tmp.stride+x1*channels]
+				row = row[:len(sum)]
+
+				for k, shade := range row {
+					sum[k] += int32(shade) * weight
+				}
+			}
+
+			out := dst.pix[y*dst.stride+x0*channels : y*dst.stride+x1*channels]
+			out = out[:len(sum)]
+
+			for k, shade := range sum {
+				out[k] = unfix(shade)
+			}
+		}
+	})
 }
 
 // LanczosResize resizes the given GrayScalePlane to the specified width and height using
@@ -184,94 +360,131 @@ func (img *RGBAPlane) LanczosResize(width, height, a int) (*RGBAPlane, error) {
 //
 // An error is returned if the target dimensions or window size are invalid.
 func (img *GrayScalePlane) LanczosResize(width, height, a int) (*GrayScalePlane, error) {
+	return img.LanczosResizeContext(context.Background(), width, height, a)
+}
 
-	// Input sanitisation
-	if width < 0 {
-		return nil, errors.New("") // TODO add real text
+// LanczosResizeContext is like LanczosResize but stops early and returns
+// ctx.Err() when ctx is cancelled.
+func (img *GrayScalePlane) LanczosResizeContext(ctx context.Context, width, height, a int) (*GrayScalePlane, error) {
+	if err := checkLanczos(width, height, a); err != nil {
+		return nil, err
 	}
 
-	if height < 0 {
-		return nil, errors.New("") // TODO add real text
-	}
+	out := NewGrayScalePlane(width, height)
+	tmp := scratch.GrayScale(width, img.Height)
+	err := lanczosGray(ctx, img.view(), tmp.view(), out.view(), a)
+	scratch.PutGrayScale(tmp)
 
-	if a < 0 {
-		return nil, errors.New("") // TODO add real text
+	if err != nil {
+		return nil, err
 	}
 
-	// Horizontal resampling
-	tmp := NewGrayScalePlane(width, img.Height)
-	ratio := float64(img.Width) / float64(width)
-	kern := coeffs(ratio, a, width)
+	return out, nil
+}
 
-	split(img.Height, func(_start, _end int) {
-		for srcY := _start; srcY < _end && srcY < img.Height; srcY++ {
-			for x := range width {
+// LanczosResizeInto is like LanczosResize but resizes the image to the
+// dimensions of dst and writes the result into it instead of allocating a new
+// plane. dst must not share its storage with img.
+func (img *GrayScalePlane) LanczosResizeInto(dst *GrayScalePlane, a int) error {
+	if err := checkLanczos(dst.Width, dst.Height, a); err != nil {
+		return err
+	}
 
-				relX := (float64(x)+0.5)*ratio - 0.5
-				var shade float64
+	tmp := scratch.GrayScale(dst.Width, img.Height)
+	lanczosGray(context.Background(), img.view(), tmp.view(), dst.view(), a)
+	scratch.PutGrayScale(tmp)
 
-				for i := range 2 * a {
-					srcX := int(math.Floor(relX) + float64(i) - (float64(a) - 1.))
-					srcX = clamp(srcX, 0, img.Width-1)
+	return nil
+}
 
-					shade += float64(img.Shades[srcY*img.Stride+srcX]) * kern[x*a*2+i] // x === j
-				}
+// lanczosGray resamples src into dst, using tmp (dst.width × src.height) to
+// hold the result of the horizontal pass.
+//
+// uint8 planes are resampled with fixed point integer arithmetic, other
+// formats in float64.
+func lanczosGray[T sample](ctx context.Context, src, tmp, dst plane[T], a int) error {
+	h := lanczosTaps(src.width, dst.width, a)
+	defer scratch.putKernel(h)
+
+	var err error
+	if src8, ok := any(src).(plane[uint8]); ok {
+		err = lanczosFixedGray(ctx, src8, any(tmp).(plane[uint8]), h)
+	} else {
+		err = lanczosFloatGray(ctx, src, tmp, h)
+	}
+	if err != nil {
+		return err
+	}
 
-				tmp.Shades[srcY*tmp.Stride+x*4] = clamp(shade, 0, 255)
-			}
-		}
-	}).Wait()
+	v := lanczosTaps(src.height, dst.height, a)
+	defer scratch.putKernel(v)
 
-	// Vertical resampling
-	out := NewGrayScalePlane(width, height)
-	ratio = float64(img.Height) / float64(height)
-	kern = coeffs(ratio, a, height)
+	return lanczosVertical(ctx, tmp, dst, v, 1)
+}
 
-	split(width, func(_start, _end int) {
-		for srcX := _start; srcX < _end && srcX < width; srcX++ {
-			for y := range height {
+// lanczosFloatGray is the horizontal pass of lanczosGray in float64.
+func lanczosFloatGray[T sample](ctx context.Context, src, tmp plane[T], h *taps) error {
+	offset := bias[T]()
 
-				relY := (float64(y)+0.5)*ratio - 0.5
-				var shade float64
+	return parallel(ctx, tmp.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := src.pix[y*src.stride : y*src.stride+src.width]
+			out := tmp.pix[y*tmp.stride : y*tmp.stride+tmp.width]
 
-				for i := range 2 * a {
-					srcY := int(math.Floor(relY) + float64(i) - (float64(a) - 1.))
-					srcY = clamp(srcY, 0, tmp.Height-1)
+			for x := x0; x < x1; x++ {
+				index := h.index[x*h.n : x*h.n+h.n]
+				weight := h.weight[x*h.n : x*h.n+h.n]
+				weight = weight[:len(index)]
+				var shade float64
 
-					shade += float64(tmp.Shades[srcY*tmp.Stride+srcX]) * kern[y*a*2+i] // y === j
+				for i, srcX := range index {
+					shade += float64(row[srcX]) * weight[i]
 				}
 
-				out.Shades[y*tmp.Stride+srcX*4] = clamp(shade, 0, 255)
+				out[x] = T(clamp(shade, 0, 255) + offset)
 			}
 		}
-	}).Wait()
-
-	return out, nil
+	})
 }
 
-func (img *EdgePlane) LanczosResize(width, height, a int) (*EdgePlane, error) {
+// lanczosFixedGray is the horizontal pass of lanczosGray in fixed point.
+func lanczosFixedGray(ctx context.Context, src, tmp plane[uint8], h *taps) error {
+	return parallel(ctx, tmp.width, src.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := src.pix[y*src.stride : y*src.stride+src.width]
+			out := tmp.pix[y*tmp.stride : y*tmp.stride+tmp.width]
+
+			for x := x0; x < x1; x++ {
+				index := h.index[x*h.n : x*h.n+h.n]
+				weight := h.fixed[x*h.n : x*h.n+h.n]
+				weight = weight[:len(index)]
+				var shade int32
+
+				for i, srcX := range index {
+					shade += int32(row[srcX]) * weight[i]
+				}
 
-	// Input sanitisation
-	if width < 0 {
-		return nil, errors.New("") // TODO add real text
-	}
+				out[x] = unfix(shade)
+			}
+		}
+	})
+}
 
-	if height < 0 {
-		return nil, errors.New("") // TODO add real text
-	}
+func (img *EdgePlane) LanczosResize(width, height, a int) (*EdgePlane, error) {
 
-	if a < 0 {
-		return nil, errors.New("") // TODO add real text
+	if err := checkLanczos(width, height, a); err != nil {
+		return nil, err
 	}
 
 	// Horizontal resampling
 	tmp := NewEdgePlane(width, img.Height)
 	ratio := float64(img.Width) / float64(width)
-	kern := coeffs(ratio, a, width)
+	kern := make([]float64, width*2*a)
+	coeffs(kern, ratio, a, width)
 
-	split(img.Height, func(_start, _end int) {
-		for srcY := _start; srcY < _end && srcY < img.Height; srcY++ {
-			for x := range width {
+	parallel(context.Background(), width, img.Height, func(x0, y0, x1, y1 int) {
+		for srcY := y0; srcY < y1; srcY++ {
+			for x := x0; x < x1; x++ {
 
 				relX := (float64(x)+0.5)*ratio - 0.5
 				var gradiant, angle float64
@@ -296,16 +509,17 @@ func (img *EdgePlane) LanczosResize(width, height, a int) (*EdgePlane, error) {
 				// tmp.Pairs[index+1] = mod(angle, math.Pi)
 			}
 		}
-	}).Wait()
+	})
 
 	// Vertical resampling
 	out := NewEdgePlane(width, height)
 	ratio = float64(img.Height) / float64(height)
-	kern = coeffs(ratio, a, height)
+	kern = make([]float64, height*2*a)
+	coeffs(kern, ratio, a, height)
 
-	split(width, func(_start, _end int) {
-		for srcX := _start; srcX < _end && srcX < width; srcX++ {
-			for y := range height {
+	parallel(context.Background(), width, height, func(x0, y0, x1, y1 int) {
+		for srcX := x0; srcX < x1; srcX++ {
+			for y := y0; y < y1; y++ {
 
 				relY := (float64(y)+0.5)*ratio - 0.5
 				var gradiant, angle float64
@@ -321,7 +535,7 @@ func (img *EdgePlane) LanczosResize(width, height, a int) (*EdgePlane, error) {
 					angle += float64(tmp.Gradient[index+1]) * coeff
 				}
 
-				index := y*tmp.Stride + srcX*4
+				index := y*out.Stride + srcX*2
 				out.Gradient[index] = gradiant
 				out.Gradient[index+1] = clamp(angle, -float64(math.Pi), float64(math.Pi))
 
@@ -330,7 +544,7 @@ func (img *EdgePlane) LanczosResize(width, height, a int) (*EdgePlane, error) {
 				// out.Pairs[index+1] = mod(angle, math.Pi)
 			}
 		}
-	}).Wait()
+	})
 
 	return out, nil
 }
@@ -0,0 +1,165 @@
+package filters
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Pool recycles planes between calls, keyed by their dimensions.
+//
+// Streaming workloads such as video or watch mode process frames of the same
+// size over and over. Combined with the destination passing variants of the
+// filters (InverseInto, ToGrayScaleInto, ...), a Pool removes the per-frame
+// allocations that would otherwise put pressure on the garbage collector:
+//
+//	gray := pool.GrayScale(img.Width, img.Height)
+//	img.ToGrayScaleInto(gray)
+//	// ...
+//	pool.PutGrayScale(gray)
+//
+// Planes returned by a Pool are tightly packed but their content is
+// undefined. Planes are held through sync.Pool, so idle ones are released
+// during garbage collection.
+//
+// The zero value is ready to use and a Pool is safe for concurrent use.
+type Pool struct {
+	rgba    shelf[*RGBAPlane]
+	rgba8   shelf[*RGBA8Plane]
+	rgba32  shelf[*RGBA32Plane]
+	gray    shelf[*GrayScalePlane]
+	gray8   shelf[*GrayScale8Plane]
+	gray32  shelf[*GrayScale32Plane]
+	kernels shelf[*taps]
+}
+
+// scratch holds the intermediate planes and kernels of the filters themselves.
+var scratch Pool
+
+// shelf is a set of sync.Pool, one per plane dimension.
+type shelf[P any] struct {
+	mu    sync.RWMutex
+	pools map[[2]int]*sync.Pool
+}
+
+func (s *shelf[P]) get(width, height int, alloc func(width, height int) P) P {
+	s.mu.RLock()
+	pool := s.pools[[2]int{width, height}]
+	s.mu.RUnlock()
+
+	if pool != nil {
+		if p := pool.Get(); p != nil {
+			return p.(P)
+		}
+	}
+
+	return alloc(width, height)
+}
+
+func (s *shelf[P]) put(width, height int, p P) {
+	key := [2]int{width, height}
+
+	s.mu.RLock()
+	pool := s.pools[key]
+	s.mu.RUnlock()
+
+	if pool == nil {
+		s.mu.Lock()
+		if s.pools == nil {
+			s.pools = make(map[[2]int]*sync.Pool)
+		}
+		if pool = s.pools[key]; pool == nil {
+			pool = &sync.Pool{}
+			s.pools[key] = pool
+		}
+		s.mu.Unlock()
+	}
+
+	pool.Put(p)
+}
+
+// RGBA returns an RGBAPlane of the given dimensions.
+func (p *Pool) RGBA(width, height int) *RGBAPlane {
+	return p.rgba.get(width, height, NewRGBAPlane)
+}
+
+// PutRGBA returns img to the pool. img must not be used afterwards.
+func (p *Pool) PutRGBA(img *RGBAPlane) {
+	p.rgba.put(img.Width, img.Height, img)
+}
+
+// RGBA8 returns an RGBA8Plane of the given dimensions.
+func (p *Pool) RGBA8(width, height int) *RGBA8Plane {
+	return p.rgba8.get(width, height, NewRGBA8Plane)
+}
+
+// PutRGBA8 returns img to the pool. img must not be used afterwards.
+func (p *Pool) PutRGBA8(img *RGBA8Plane) {
+	p.rgba8.put(img.Width, img.Height, img)
+}
+
+// RGBA32 returns an RGBA32Plane of the given dimensions.
+func (p *Pool) RGBA32(width, height int) *RGBA32Plane {
+	return p.rgba32.get(width, height, NewRGBA32Plane)
+}
+
+// PutRGBA32 returns img to the pool. img must not be used afterwards.
+func (p *Pool) PutRGBA32(img *RGBA32Plane) {
+	p.rgba32.put(img.Width, img.Height, img)
+}
+
+// GrayScale returns a GrayScalePlane of the given dimensions.
+func (p *Pool) GrayScale(width, height int) *GrayScalePlane {
+	return p.gray.get(width, height, NewGrayScalePlane)
+}
+
+// PutGrayScale returns img to the pool. img must not be used afterwards.
+func (p *Pool) PutGrayScale(img *GrayScalePlane) {
+	p.gray.put(img.Width, img.Height, img)
+}
+
+// GrayScale8 returns a GrayScale8Plane of the given dimensions.
+func (p *Pool) GrayScale8(width, height int) *GrayScale8Plane {
+	return p.gray8.get(width, height, NewGrayScale8Plane)
+}
+
+// PutGrayScale8 returns img to the pool. img must not be used afterwards.
+func (p *Pool) PutGrayScale8(img *GrayScale8Plane) {
+	p.gray8.put(img.Width, img.Height, img)
+}
+
+// GrayScale32 returns a GrayScale32Plane of the given dimensions.
+func (p *Pool) GrayScale32(width, height int) *GrayScale32Plane {
+	return p.gray32.get(width, height, NewGrayScale32Plane)
+}
+
+// PutGrayScale32 returns img to the pool. img must not be used afterwards.
+func (p *Pool) PutGrayScale32(img *GrayScale32Plane) {
+	p.gray32.put(img.Width, img.Height, img)
+}
+
+// kernel returns storage for the Lanczos taps of dimension output samples
+// with a window of size a.
+func (p *Pool) kernel(dimension, a int) *taps {
+	return p.kernels.get(dimension*2*a, 2*a, func(size, n int) *taps {
+		return &taps{
+			n:      n,
+			index:  make([]int, size),
+			weight: make([]float64, size),
+			fixed:  make([]int32, size),
+		}
+	})
+}
+
+func (p *Pool) putKernel(t *taps) {
+	p.kernels.put(len(t.index), t.n, t)
+}
+
+// checkSize returns an error naming the filter if the destination plane
+// does not have the expected dimensions.
+func checkSize(filter string, width, height, wantWidth, wantHeight int) error {
+	if width != wantWidth || height != wantHeight {
+		return fmt.Errorf("%s: destination is %dx%d, want %dx%d", filter, width, height, wantWidth, wantHeight)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package filters_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+func TestIntoMatchesAllocating(t *testing.T) {
+	img := load(t, images[2].path)
+	var pool filters.Pool
+
+	inverse := pool.RGBA(img.Width, img.Height)
+	if err := img.InverseInto(inverse); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(inverse.RGBA, img.Inverse().RGBA) {
+		t.Error("InverseInto differs from Inverse")
+	}
+
+	gray := pool.GrayScale(img.Width, img.Height)
+	if err := img.ToGrayScaleInto(gray); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(gray.Shades, img.ToGrayScale().Shades) {
+		t.Error("ToGrayScaleInto differs from ToGrayScale")
+	}
+
+	want, _ := gray.BayerDithering(3)
+	if err := gray.BayerDitheringInto(gray, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(gray.Shades, want.Shades) {
+		t.Error("in place BayerDitheringInto differs from BayerDithering")
+	}
+
+	resized := pool.RGBA(img.Width/3, img.Height/5)
+	if err := img.LanczosResizeInto(resized, 3); err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := img.LanczosResize(img.Width/3, img.Height/5, 3); !slices.Equal(resized.RGBA, want.RGBA) {
+		t.Error("LanczosResizeInto differs from LanczosResize")
+	}
+
+	if err := img.InverseInto(resized); err == nil {
+		t.Error("InverseInto accepted a destination of the wrong size")
+	}
+}
+
+// BenchmarkFrame renders the same frame repeatedly, as a video or watch loop
+// would, with and without destination passing. Compare the allocs/op column.
+func BenchmarkFrame(b *testing.B) {
+	img := load(b, images[2].path)
+	width, height := img.Width/2, img.Height/4
+
+	b.Run("alloc", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			small, _ := img.LanczosResize(width, height, 3)
+			gray := small.Inverse().ToGrayScale()
+			gray.BayerDithering(3)
+		}
+	})
+
+	b.Run("into", func(b *testing.B) {
+		var pool filters.Pool
+		small := pool.RGBA(width, height)
+		gray := pool.GrayScale(width, height)
+
+		b.ReportAllocs()
+		for b.Loop() {
+			img.LanczosResizeInto(small, 3)
+			small.InverseInto(small)
+			small.ToGrayScaleInto(gray)
+			gray.BayerDitheringInto(gray, 3)
+		}
+	})
+}
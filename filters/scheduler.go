@@ -0,0 +1,169 @@
+package filters
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// tileSize is the largest width and height of the tiles handed to Run callbacks.
+const tileSize = 64
+
+// Scheduler distributes tile based work over a fixed set of worker goroutines.
+//
+// Workers are started on first use and reused by every call, so filters do not
+// spawn goroutines of their own. Each call cuts its area into 2D tiles that the
+// workers, and the calling goroutine, claim one at a time from a shared
+// counter: a worker that finishes early simply takes the next tile, which
+// keeps uneven workloads balanced.
+//
+// The calling goroutine always takes part in its own call. Calls can therefore
+// be nested without deadlocking, and a Scheduler with a single worker runs
+// everything on the caller.
+type Scheduler struct {
+	workers int
+
+	start, stop sync.Once
+	jobs        chan *job
+	done        chan struct{}
+}
+
+// NewScheduler returns a Scheduler running work on the given number of
+// goroutines, the caller included. workers < 1 is treated as 1.
+func NewScheduler(workers int) *Scheduler {
+	return &Scheduler{
+		workers: max(1, workers),
+		jobs:    make(chan *job),
+		done:    make(chan struct{}),
+	}
+}
+
+// Workers returns the number of goroutines the scheduler runs work on.
+func (s *Scheduler) Workers() int { return s.workers }
+
+// Close stops the workers once they are done with their current tile.
+// Calls to Run made after Close run on the calling goroutine only.
+func (s *Scheduler) Close() {
+	s.start.Do(func() {})
+	s.stop.Do(func() { close(s.done) })
+}
+
+// Run calls fn for every tile of the width × height area and waits for all of
+// them to complete. Tiles are half open rectangles [x0, x1) × [y0, y1), at
+// most tileSize pixels wide and high, that cover the area exactly once.
+//
+// When ctx is cancelled, tiles that have not started yet are skipped and Run
+// returns ctx.Err(). Tiles already running are not interrupted.
+func (s *Scheduler) Run(ctx context.Context, width, height int, fn func(x0, y0, x1, y1 int)) error {
+	if width <= 0 || height <= 0 {
+		return ctx.Err()
+	}
+
+	s.start.Do(func() {
+		for range s.workers - 1 {
+			go s.work()
+		}
+	})
+
+	j := &job{ctx: ctx, fn: fn, width: width, height: height}
+
+	// Cut the area into tiles, making them shorter until there are enough of
+	// them to keep every worker busy.
+	j.tileWidth = min(width, tileSize)
+	j.tileHeight = tileSize
+	j.columns = (width + j.tileWidth - 1) / j.tileWidth
+	for j.tileHeight > 1 && j.columns*((height+j.tileHeight-1)/j.tileHeight) < 4*s.workers {
+		j.tileHeight /= 2
+	}
+	j.tiles = int64(j.columns * ((height + j.tileHeight - 1) / j.tileHeight))
+
+	j.wg.Add(int(j.tiles))
+
+	// Hand the job to idle workers only; busy ones will not be waited for.
+offer:
+	for range min(int64(s.workers-1), j.tiles-1) {
+		select {
+		case s.jobs <- j:
+		default:
+			break offer
+		}
+	}
+
+	j.work()
+	j.wg.Wait()
+
+	return ctx.Err()
+}
+
+func (s *Scheduler) work() {
+	for {
+		select {
+		case j := <-s.jobs:
+			j.work()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// job is a single call to Scheduler.Run.
+type job struct {
+	ctx context.Context
+	fn  func(x0, y0, x1, y1 int)
+
+	width, height         int
+	tileWidth, tileHeight int
+	columns               int
+	tiles                 int64
+
+	next atomic.Int64
+	wg   sync.WaitGroup
+}
+
+func (j *job) work() {
+	for {
+		tile := j.next.Add(1) - 1
+		if tile >= j.tiles {
+			return
+		}
+
+		if j.ctx.Err() == nil {
+			x0 := int(tile%int64(j.columns)) * j.tileWidth
+			y0 := int(tile/int64(j.columns)) * j.tileHeight
+			j.fn(x0, y0, min(x0+j.tileWidth, j.width), min(y0+j.tileHeight, j.height))
+		}
+
+		j.wg.Done()
+	}
+}
+
+// scheduler is the Scheduler used by every filter.
+var scheduler atomic.Pointer[Scheduler]
+
+func init() {
+	scheduler.Store(NewScheduler(runtime.GOMAXPROCS(0)))
+}
+
+// SetWorkers replaces the scheduler used by the filters with one running on n
+// goroutines. By default filters use runtime.GOMAXPROCS(0) goroutines.
+func SetWorkers(n int) {
+	if old := scheduler.Swap(NewScheduler(n)); old != nil {
+		old.Close()
+	}
+}
+
+// Workers returns the number of goroutines the filters run on.
+func Workers() int { return scheduler.Load().Workers() }
+
+// parallel runs fn over the tiles of the width × height area on the filters'
+// scheduler. See Scheduler.Run.
+func parallel(ctx context.Context, width, height int, fn func(x0, y0, x1, y1 int)) error {
+	return scheduler.Load().Run(ctx, width, height, fn)
+}
+
+// rows runs fn over ranges of whole rows, for work that cannot be split
+// horizontally.
+func rows(ctx context.Context, height int, fn func(start, end int)) error {
+	return parallel(ctx, 1, height, func(_, start, _, end int) { fn(start, end) })
+}
@@ -0,0 +1,86 @@
+package filters_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+func TestSchedulerCoversAreaOnce(t *testing.T) {
+	for _, workers := range []int{1, 3, 8} {
+		s := filters.NewScheduler(workers)
+		defer s.Close()
+
+		for _, size := range [][2]int{{1, 1}, {7, 3}, {64, 64}, {200, 13}, {1, 500}} {
+			width, height := size[0], size[1]
+			hits := make([]atomic.Int32, width*height)
+
+			err := s.Run(context.Background(), width, height, func(x0, y0, x1, y1 int) {
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						hits[y*width+x].Add(1)
+					}
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range hits {
+				if n := hits[i].Load(); n != 1 {
+					t.Fatalf("%d workers, %dx%d: pixel %d visited %d times", workers, width, height, i, n)
+				}
+			}
+		}
+	}
+}
+
+func TestSchedulerNested(t *testing.T) {
+	s := filters.NewScheduler(2)
+	defer s.Close()
+
+	var total atomic.Int64
+	s.Run(context.Background(), 4, 4, func(x0, y0, x1, y1 int) {
+		for range (x1 - x0) * (y1 - y0) {
+			s.Run(context.Background(), 10, 10, func(x0, y0, x1, y1 int) {
+				total.Add(int64((x1 - x0) * (y1 - y0)))
+			})
+		}
+	})
+
+	if total.Load() != 16*100 {
+		t.Errorf("nested runs covered %d pixels, want %d", total.Load(), 16*100)
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := filters.NewScheduler(1)
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tiles := 0
+
+	err := s.Run(ctx, 1000, 1000, func(x0, y0, x1, y1 int) {
+		tiles++
+		cancel()
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+	if tiles != 1 {
+		t.Errorf("%d tiles ran after cancellation, want 1", tiles)
+	}
+}
+
+func TestInverseContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := filters.NewGrayScalePlane(64, 64).InverseContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("InverseContext returned %v, want context.Canceled", err)
+	}
+}
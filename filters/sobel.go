@@ -1,6 +1,7 @@
 package filters
 
 import (
+	"context"
 	"math"
 )
 
@@ -10,8 +11,15 @@ import (
 // Pixels near the image boundary are handled by ignoring samples that fall
 // outside the image bounds.
 //
-// The computation is parallelized across image rows.
+// The computation is parallelized across image tiles.
 func (img *GrayScalePlane) SobelEdgeDetection() *EdgePlane {
+	out, _ := img.SobelEdgeDetectionContext(context.Background())
+	return out
+}
+
+// SobelEdgeDetectionContext is like SobelEdgeDetection but stops early and
+// returns ctx.Err() when ctx is cancelled.
+func (img *GrayScalePlane) SobelEdgeDetectionContext(ctx context.Context) (*EdgePlane, error) {
 	// For each pixel, the Sobel operator estimates the horizontal (Gx) and vertical
 	// (Gy) intensity gradients using a 3×3 convolution kernel. The resulting edge
 	//
@@ -26,45 +34,138 @@ func (img *GrayScalePlane) SobelEdgeDetection() *EdgePlane {
 
 	// Allocate the output edge plane with matching dimensions.
 	out := NewEdgePlane(img.Width, img.Height)
+	if err := sobel(ctx, img.view(), out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// sobelKernel is the Sobel kernel for the horizontal gradient (Gx).
+// The vertical gradient (Gy) is obtained by transposing the kernel.
+var sobelKernel = [9]float64{
+	-1, 0, 1,
+	-2, 0, 2,
+	-1, 0, 1,
+}
 
-	// Sobel kernel for horizontal gradient (Gx).
-	// The vertical gradient (Gy) is obtained by transposing the kernel.
-	kern := []float64{
-		-1, 0, 1,
-		-2, 0, 2,
-		-1, 0, 1,
+// sobel computes the edges of img into out.
+//
+// Interior pixels take an unrolled path reading three contiguous rows, in
+// integer arithmetic for uint8 planes. Only the one pixel wide border goes
+// through the bounds checked kernel of sobelAt.
+func sobel[T sample](ctx context.Context, img plane[T], out *EdgePlane) error {
+	if img8, ok := any(img).(plane[uint8]); ok {
+		return sobelFixed(ctx, img8, out)
 	}
 
-	// Split the work across row ranges to enable parallel processing.
-	split(img.Height, func(start, end int) {
-		for y := start; y < end && y < img.Height; y++ {
-			for x := range img.Width {
-				var sumX, sumY float64
-
-				// Apply the 3×3 Sobel kernel centered at (x, y).
-				for j := range 3 {
-					srcY := y - 1 + j
-					for i := range 3 {
-						srcX := x - 1 + i
-
-						if 0 <= srcY && srcY < img.Height && 0 <= srcX && srcX < img.Width {
-							// Fetch the source pixel intensity.
-							pix := float64(img.Shades[srcY*img.Stride+srcX])
-
-							// Accumulate horizontal and vertical gradients.
-							sumX += pix * kern[j*3+i] // K
-							sumY += pix * kern[j+i*3] // K_T
-						}
-					}
+	// Split the work across tiles to enable parallel processing.
+	return parallel(ctx, img.width, img.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			if y == 0 || y == img.height-1 || img.width < 3 {
+				for x := x0; x < x1; x++ {
+					sobelAt(img, out, x, y)
 				}
+				continue
+			}
 
-				// Store the gradient magnitude and angle for this pixel.
-				index := y*out.Stride + 2*x
-				out.Gradient[index] = math.Sqrt(sumX*sumX + sumY*sumY)
-				out.Gradient[index+1] = math.Mod(math.Atan2(sumY, sumX)+2*math.Pi, 2*math.Pi)
+			top := img.pix[(y-1)*img.stride : (y-1)*img.stride+img.width]
+			mid := img.pix[y*img.stride : y*img.stride+img.width]
+			bot := img.pix[(y+1)*img.stride : (y+1)*img.stride+img.width]
+			mid, bot = mid[:len(top)], bot[:len(top)]
+			row := out.Gradient[y*out.Stride : y*out.Stride+img.width*2]
+
+			for x := max(x0, 1); x < min(x1, img.width-1); x++ {
+				a0, b0, c0 := float64(top[x-1]), float64(top[x]), float64(top[x+1])
+				a1, c1 := float64(mid[x-1]), float64(mid[x+1])
+				a2, b2, c2 := float64(bot[x-1]), float64(bot[x]), float64(bot[x+1])
+
+				gx := -a0 + c0 - 2*a1 + 2*c1 - a2 + c2
+				gy := -a0 - 2*b0 - c0 + a2 + 2*b2 + c2
+				gradient(row[x*2:x*2+2], gx, gy)
+			}
+
+			if x0 == 0 {
+				sobelAt(img, out, 0, y)
+			}
+			if x1 == img.width {
+				sobelAt(img, out, img.width-1, y)
 			}
 		}
-	}).Wait()
+	})
+}
 
-	return out
+// sobelFixed is sobel for uint8 planes, computing gradients in int32.
+func sobelFixed(ctx context.Context, img plane[uint8], out *EdgePlane) error {
+	return parallel(ctx, img.width, img.height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			if y == 0 || y == img.height-1 || img.width < 3 {
+				for x := x0; x < x1; x++ {
+					sobelAt(img, out, x, y)
+				}
+				continue
+			}
+
+			top := img.pix[(y-1)*img.stride : (y-1)*img.stride+img.width]
+			mid := img.pix[y*img.stride : y*img.stride+img.width]
+			bot := img.pix[(y+1)*img.stride : (y+1)*img.stride+img.width]
+			mid, bot = mid[:len(top)], bot[:len(top)]
+			row := out.Gradient[y*out.Stride : y*out.Stride+img.width*2]
+
+			for x := max(x0, 1); x < min(x1, img.width-1); x++ {
+				a0, b0, c0 := int32(top[x-1]), int32(top[x]), int32(top[x+1])
+				a1, c1 := int32(mid[x-1]), int32(mid[x+1])
+				a2, b2, c2 := int32(bot[x-1]), int32(bot[x]), int32(bot[x+1])
+
+				gx := -a0 + c0 - 2*a1 + 2*c1 - a2 + c2
+				gy := -a0 - 2*b0 - c0 + a2 + 2*b2 + c2
+				gradient(row[x*2:x*2+2], float64(gx), float64(gy))
+			}
+
+			if x0 == 0 {
+				sobelAt(img, out, 0, y)
+			}
+			if x1 == img.width {
+				sobelAt(img, out, img.width-1, y)
+			}
+		}
+	})
+}
+
+// sobelAt computes the edge at (x, y), ignoring samples outside of the image.
+func sobelAt[T sample](img plane[T], out *EdgePlane, x, y int) {
+	var sumX, sumY float64
+
+	// Apply the 3×3 Sobel kernel centered at (x, y).
+	for j := range 3 {
+		srcY := y - 1 + j
+		for i := range 3 {
+			srcX := x - 1 + i
+
+			if 0 <= srcY && srcY < img.height && 0 <= srcX && srcX < img.width {
+				// Fetch the source pixel intensity.
+				pix := float64(img.pix[srcY*img.stride+srcX])
+
+				// Accumulate horizontal and vertical gradients.
+				sumX += pix * sobelKernel[j*3+i] // K
+				sumY += pix * sobelKernel[j+i*3] // K_T
+			}
+		}
+	}
+
+	// Store the gradient magnitude and angle for this pixel.
+	index := y*out.Stride + 2*x
+	gradient(out.Gradient[index:index+2], sumX, sumY)
+}
+
+// gradient stores the magnitude of (gx, gy) and its angle in [0, 2π) into g.
+func gradient(g []float64, gx, gy float64) {
+	g = g[:2]
+	g[0] = math.Sqrt(gx*gx + gy*gy)
+
+	angle := math.Atan2(gy, gx)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	g[1] = angle
 }
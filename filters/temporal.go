@@ -0,0 +1,129 @@
+package filters
+
+import (
+	"context"
+	"errors"
+)
+
+// The Temporal variants of the filters render the successive frames of an
+// animation or a video. Rendered on its own, a pixel close to a threshold
+// crosses it back and forth from one frame to the next with noise or slow
+// fades, which makes glyphs flicker even in static regions. These variants
+// take the output of the previous frame and apply hysteresis: a pixel only
+// changes side once it is more than hysteresis, in gray levels, past the
+// threshold. prev may be nil, or of another size, for the first frame, which
+// is then rendered as the plain filter does.
+
+// BayerDitheringTemporal is like BayerDitheringContext, each pixel keeping
+// its value in prev unless it is more than hysteresis past its threshold.
+func (img *GrayScalePlane) BayerDitheringTemporal(ctx context.Context, n int, prev *GrayScalePlane, hysteresis float64) (*GrayScalePlane, error) {
+	if n < 1 || n > 8 {
+		return nil, errors.New("BayerDithering: n must be between 1 and 8")
+	}
+	if prev == nil || prev.Width != img.Width || prev.Height != img.Height {
+		return img.BayerDitheringContext(ctx, n)
+	}
+
+	M := matrix(n)
+	mask := 1<<n - 1
+	out := NewGrayScalePlane(img.Width, img.Height)
+
+	err := parallel(ctx, img.Width, img.Height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				lhs := float64(uint8(img.Shades[y*img.Stride+x]))
+				rhs := float64(M[y&mask][x&mask])
+
+				if prev.Shades[y*prev.Stride+x] > 0 {
+					rhs -= hysteresis
+				} else {
+					rhs += hysteresis
+				}
+
+				if lhs > rhs {
+					out.Shades[y*out.Stride+x] = 255
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// BrailleTemporal is like Braille, each dot keeping its state in prev unless
+// its pixel is more than hysteresis past threshold.
+func (img *GrayScalePlane) BrailleTemporal(threshold float64, prev *AsciiPlane, hysteresis float64) *AsciiPlane {
+	out := NewAsciiPlane(img.Width/2, img.Height/4)
+	if prev == nil || prev.Width != out.Width || prev.Height != out.Height {
+		braille(img.view(), out, threshold)
+		return out
+	}
+
+	parallel(context.Background(), out.Width, out.Height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				var last uint16
+				if c := prev.Chars[y*prev.Stride+x]; 0x2800 <= c && c <= 0x28ff {
+					last = uint16(c - 0x2800)
+				}
+
+				char := uint16(0x2800)
+				for j := range 4 {
+					for i := range 2 {
+						limit := threshold + hysteresis
+						if last&dotMatrix[j][i] != 0 {
+							limit = threshold - hysteresis
+						}
+
+						if img.Shades[(y*4+j)*img.Stride+(x*2+i)] >= limit {
+							char += dotMatrix[j][i]
+						}
+					}
+				}
+
+				out.Chars[y*out.Stride+x] = rune(char)
+			}
+		}
+	})
+
+	return out
+}
+
+// AsciiTemporal is like Ascii, each pixel keeping its character in prev as
+// long as its intensity is within hysteresis of the range of that character.
+func (img *GrayScalePlane) AsciiTemporal(palette []rune, prev *AsciiPlane, hysteresis float64) *AsciiPlane {
+	out := NewAsciiPlane(img.Width, img.Height)
+	if prev == nil || prev.Width != out.Width || prev.Height != out.Height || len(palette) < 2 {
+		ascii(img.view(), out, palette)
+		return out
+	}
+
+	buckets := make(map[rune]int, len(palette))
+	for i, char := range palette {
+		buckets[char] = i
+	}
+	step := 255. / float64(len(palette)-1) // range of lum each bucket covers
+
+	parallel(context.Background(), out.Width, out.Height, func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				lum := img.Shades[y*img.Stride+x]
+				bucket := int((lum / 255.) * float64(len(palette)-1))
+
+				if last, ok := buckets[prev.Chars[y*prev.Stride+x]]; ok && last != bucket {
+					low, high := float64(last)*step-hysteresis, float64(last+1)*step+hysteresis
+					if low <= lum && lum < high {
+						bucket = last
+					}
+				}
+
+				out.Chars[y*out.Stride+x] = palette[bucket]
+			}
+		}
+	})
+
+	return out
+}
@@ -0,0 +1,82 @@
+package filters_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// gray returns a plane of the given size filled with v.
+func gray(width, height int, v float64) *filters.GrayScalePlane {
+	img := filters.NewGrayScalePlane(width, height)
+	for i := range img.Shades {
+		img.Shades[i] = v
+	}
+
+	return img
+}
+
+func TestTemporal(t *testing.T) {
+	palette := []rune(" .:#") // buckets of 85 gray levels
+
+	// A pixel wandering around the boundary at 85 keeps its character with
+	// hysteresis, and changes past it.
+	prev := gray(2, 4, 88).AsciiTemporal(palette, nil, 10)
+	if next := gray(2, 4, 82).AsciiTemporal(palette, prev, 10); !slices.Equal(next.Chars, prev.Chars) {
+		t.Errorf("ascii flickered from %q to %q", string(prev.Chars), string(next.Chars))
+	}
+	if next := gray(2, 4, 70).AsciiTemporal(palette, prev, 10); next.Chars[0] != ' ' {
+		t.Errorf("ascii kept %q past the hysteresis", next.Chars[0])
+	}
+
+	on := gray(2, 4, 130).BrailleTemporal(128, nil, 10)
+	if next := gray(2, 4, 125).BrailleTemporal(128, on, 10); next.Chars[0] != on.Chars[0] {
+		t.Errorf("braille dots went from %q to %q", on.Chars[0], next.Chars[0])
+	}
+	off := gray(2, 4, 125).BrailleTemporal(128, nil, 10)
+	if next := gray(2, 4, 130).BrailleTemporal(128, off, 10); next.Chars[0] != off.Chars[0] {
+		t.Errorf("braille dots went from %q to %q", off.Chars[0], next.Chars[0])
+	}
+
+	dithered, err := gray(8, 8, 130).BayerDitheringTemporal(context.Background(), 3, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := gray(8, 8, 126).BayerDitheringTemporal(context.Background(), 3, dithered, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(next.Shades, dithered.Shades) {
+		t.Error("dithering flickered")
+	}
+
+	// Without a previous frame, the plain filters are used.
+	plain, _ := gray(8, 8, 126).BayerDithering(3)
+	if next, _ := gray(8, 8, 126).BayerDitheringTemporal(context.Background(), 3, gray(4, 4, 0), 10); !slices.Equal(next.Shades, plain.Shades) {
+		t.Error("dithering of another size was used as the previous frame")
+	}
+}
+
+func TestEncodeDiff(t *testing.T) {
+	prev := filters.NewAsciiPlane(20, 2)
+	next := filters.NewAsciiPlane(20, 2)
+	for i := range prev.Chars {
+		prev.Chars[i], next.Chars[i] = 'a', 'a'
+	}
+	next.Chars[1], next.Chars[3] = 'b', 'c' // close: one span
+	next.Chars[19] = 'd'                    // far: its own span
+	next.Chars[20] = 'e'
+
+	want := "\x1b[1;2Hbac\x1b[1;20Hd\x1b[2;1He"
+	if got := filters.DefaultEncoder.EncodeDiff(prev, next); got != want {
+		t.Errorf("EncodeDiff = %q, want %q", got, want)
+	}
+	if got := filters.DefaultEncoder.EncodeDiff(next, next); got != "" {
+		t.Errorf("EncodeDiff of the same plane = %q", got)
+	}
+	if got := filters.DefaultEncoder.EncodeDiff(nil, next); got[:7] != "\x1b[H\x1b[2J" {
+		t.Errorf("EncodeDiff without previous frame = %q", got)
+	}
+}
@@ -1,5 +1,7 @@
 package filters
 
+import "image/color"
+
 // The use of float64 for pixel storage is intentional: it allows image data
 // to remain in a consistent, high precision representation while passing
 // through multiple filters and transformations, thereby reducing cumulative
@@ -40,6 +42,10 @@ func NewRGBAPlane(width, height int) *RGBAPlane {
 	}
 }
 
+func (img *RGBAPlane) view() plane[float64] {
+	return plane[float64]{img.RGBA, img.Width, img.Height, img.Stride}
+}
+
 // GrayscalePlane represents a two-dimensional grayscale image stored as a flat
 // slice of float64 values.
 //
@@ -74,6 +80,10 @@ func NewGrayScalePlane(width, height int) *GrayScalePlane {
 	}
 }
 
+func (img *GrayScalePlane) view() plane[float64] {
+	return plane[float64]{img.Shades, img.Width, img.Height, img.Stride}
+}
+
 // AsciiPlane represents a two-dimensional image where each pixel is encoded
 // as a single Unicode character.
 //
@@ -108,11 +118,44 @@ func NewAsciiPlane(width, height int) *AsciiPlane {
 	}
 }
 
-// AsciiColorPlane represents a two-dimensional image where each pixel is encoded
-// as a colored ASCII element.
+// Attr is a set of text attributes of a Cell.
+type Attr uint8
+
+const (
+	Bold Attr = 1 << iota
+	Faint
+	Italic
+	Underline
+	Blink
+	Reverse
+	Strike
+)
+
+// Cell is a character of an AsciiColorPlane along with its colors and
+// attributes.
+type Cell struct {
+	Rune rune
+
+	// FG and BG are the foreground and background colors. A zero alpha
+	// stands for the default color of the terminal.
+	FG, BG color.RGBA
+
+	Attr Attr
+}
+
+// Cells is an ASCII render whose cells can be read back, implemented by
+// AsciiPlane and AsciiColorPlane.
+type Cells interface {
+	Width_() int
+	Height_() int
+	At(x, y int) Cell
+}
+
+// AsciiColorPlane represents a two-dimensional image where each pixel is
+// a colored character.
 //
-// Each entry in Chars typically represents a character along with its color
-// information (for example, ANSI escape sequences).
+// Cells only hold structured data: the escape sequences of a given terminal
+// are produced by an Encoder, and Buffer uses DefaultEncoder.
 //
 // Elements are stored in row major order.
 //
@@ -120,10 +163,10 @@ func NewAsciiPlane(width, height int) *AsciiPlane {
 //
 //	(y * Stride) + (x)
 //
-// where Stride is the number of runes between vertically adjacent pixels.
+// where Stride is the number of cells between vertically adjacent pixels.
 type AsciiColorPlane struct {
-	// Chars holds the string representation of each pixel, including color data.
-	Chars []string
+	// Cells holds the character, colors and attributes of each pixel.
+	Cells []Cell
 
 	// Width and Height define the dimensions of the image in characters.
 	Width, Height int
@@ -137,7 +180,7 @@ type AsciiColorPlane struct {
 // The returned plane is tightly packed, with a stride equal to the image width.
 func NewAsciiColorPlane(width, height int) *AsciiColorPlane {
 	return &AsciiColorPlane{
-		Chars:  make([]string, width*height),
+		Cells:  make([]Cell, width*height),
 		Width:  width,
 		Height: height,
 		Stride: width,
@@ -1,33 +1,30 @@
 package filters
 
-import (
-	"cmp"
-	"runtime"
-	"sync"
-)
+import "cmp"
 
-func split(lines int, lambda func(start, end int)) *sync.WaitGroup {
-	var wg sync.WaitGroup
-	var split int
-	var cpus = runtime.GOMAXPROCS(0)
-
-	if cpus >= lines {
-		for line := range lines {
-			wg.Go(func() { lambda(line, line+1) })
-		}
-	} else {
-		if cpus == 1 {
-			split = lines
-		} else {
-			split = int(lines / (cpus - 1))
-		}
+// sample is the set of element types a plane can be backed by.
+//
+// float64 is the reference format; float32 and uint8 trade precision for
+// memory (16 and 4 bytes per RGBA pixel instead of 32).
+type sample interface {
+	~uint8 | ~float32 | ~float64
+}
 
-		for cpu := range cpus {
-			wg.Go(func() { lambda(cpu*split, (cpu+1)*split) })
-		}
-	}
+// plane is an untyped view over the storage of any of the exported plane
+// types. Filters are implemented once against plane and the exported methods
+// only wrap them.
+type plane[T sample] struct {
+	pix                   []T
+	width, height, stride int
+}
 
-	return &wg
+// bias returns the value to add to a float64 before converting it to T.
+//
+// Converting a float to an integer truncates, so integer samples get a 0.5
+// offset to round to the nearest value instead. Floating point samples get 0.
+func bias[T sample]() float64 {
+	half := 0.5
+	return half - float64(T(half))
 }
 
 // clamp constrains a value to lie within the inclusive range [lower, upper].
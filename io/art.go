@@ -0,0 +1,380 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	goio "io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// Renders are saved as:
+//
+//   - .txt: the characters alone.
+//   - .ans: the characters with their ANSI escape sequences.
+//   - .json: the native format, lossless, which also records where the
+//     render comes from.
+
+// Metadata describes the source of a render, stored by the native format.
+type Metadata struct {
+	// Source is the path of the source image, Width and Height its size.
+	Source string `json:"source,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+
+	// Pipeline describes the filters the render went through.
+	Pipeline string    `json:"pipeline,omitempty"`
+	Created  time.Time `json:"created,omitzero"`
+}
+
+// IsArt reports whether _path names a saved render rather than an image.
+// For Stdio, it tells them apart by the start of the standard input, which
+// is left to be read: renders are what no image format recognizes.
+func IsArt(_path string) bool {
+	if _path == Stdio {
+		head, _ := stdin().Peek(512)
+		_, _, err := image.DecodeConfig(bytes.NewReader(head))
+		return errors.Is(err, image.ErrFormat)
+	}
+
+	switch strings.ToLower(filepath.Ext(_path)) {
+	case ".txt", ".ans", ".json":
+		return true
+	}
+
+	return false
+}
+
+// EncodeText writes the characters of _cells, one line per row.
+func EncodeText(_w goio.Writer, _cells Cells) error {
+	w := bufio.NewWriter(_w)
+
+	for y := range _cells.Height_() {
+		for x := range _cells.Width_() {
+			char := _cells.At(x, y).Rune
+			if char == 0 {
+				char = ' '
+			}
+			w.WriteRune(char)
+		}
+		w.WriteByte('\n')
+	}
+
+	return w.Flush()
+}
+
+// EncodeANSI writes _cells with the escape sequences of _enc, one line per
+// row.
+func EncodeANSI(_w goio.Writer, _cells Cells, _enc filters.Encoder) error {
+	w := bufio.NewWriter(_w)
+
+	line := make([]filters.Cell, _cells.Width_())
+	for y := range _cells.Height_() {
+		for x := range line {
+			line[x] = _cells.At(x, y)
+			if line[x].Rune == 0 {
+				line[x].Rune = ' '
+			}
+		}
+		w.WriteString(_enc.Encode(line))
+		w.WriteByte('\n')
+	}
+
+	return w.Flush()
+}
+
+// native is the JSON document of the native format. Every cell is stored as
+// [rune, foreground, background, attributes], colors being packed as
+// 0xRRGGBBAA.
+type native struct {
+	Format  string      `json:"format"`
+	Version int         `json:"version"`
+	Width   int         `json:"width"`
+	Height  int         `json:"height"`
+	Source  *Metadata   `json:"source,omitempty"`
+	Cells   [][4]uint32 `json:"cells"`
+}
+
+const nativeFormat = "goscii"
+
+func pack(_c color.RGBA) uint32 {
+	return uint32(_c.R)<<24 | uint32(_c.G)<<16 | uint32(_c.B)<<8 | uint32(_c.A)
+}
+
+func unpack(_v uint32) color.RGBA {
+	return color.RGBA{uint8(_v >> 24), uint8(_v >> 16), uint8(_v >> 8), uint8(_v)}
+}
+
+// EncodeNative writes _cells in the native format along with _meta.
+func EncodeNative(_w goio.Writer, _cells Cells, _meta Metadata) error {
+	doc := native{
+		Format:  nativeFormat,
+		Version: 1,
+		Width:   _cells.Width_(),
+		Height:  _cells.Height_(),
+		Cells:   make([][4]uint32, 0, _cells.Width_()*_cells.Height_()),
+	}
+
+	if _meta != (Metadata{}) {
+		doc.Source = &_meta
+	}
+
+	for y := range doc.Height {
+		for x := range doc.Width {
+			cell := _cells.At(x, y)
+			doc.Cells = append(doc.Cells, [4]uint32{uint32(cell.Rune), pack(cell.FG), pack(cell.BG), uint32(cell.Attr)})
+		}
+	}
+
+	return json.NewEncoder(_w).Encode(doc)
+}
+
+// DecodeNative reads a render written by EncodeNative.
+func DecodeNative(_r goio.Reader) (*filters.AsciiColorPlane, Metadata, error) {
+	var doc native
+	if err := json.NewDecoder(_r).Decode(&doc); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	if doc.Format != nativeFormat || doc.Version != 1 {
+		return nil, Metadata{}, fmt.Errorf("native: unsupported format %q version %d", doc.Format, doc.Version)
+	}
+	if err := checkDimensions(doc.Width, doc.Height); err != nil {
+		return nil, Metadata{}, fmt.Errorf("native: %w", err)
+	}
+	if len(doc.Cells) != doc.Width*doc.Height {
+		return nil, Metadata{}, fmt.Errorf("native: %d cells for %dx%d", len(doc.Cells), doc.Width, doc.Height)
+	}
+
+	out := filters.NewAsciiColorPlane(doc.Width, doc.Height)
+	for i, cell := range doc.Cells {
+		out.Cells[i] = filters.Cell{
+			Rune: rune(cell[0]),
+			FG:   unpack(cell[1]),
+			BG:   unpack(cell[2]),
+			Attr: filters.Attr(cell[3]),
+		}
+	}
+
+	var meta Metadata
+	if doc.Source != nil {
+		meta = *doc.Source
+	}
+
+	return out, meta, nil
+}
+
+// DecodeANSI parses text with SGR escape sequences, such as .ans files or
+// the output of Buffer, into an AsciiColorPlane. Plain text is parsed as well.
+//
+// Rows shorter than the longest one are padded with blank cells. Tabs are
+// expanded to the next multiple of 8 columns, escape sequences other than SGR
+// are skipped, and a SUB character (0x1A) ends the text, hiding the SAUCE
+// record of ANSI art files. Text is read as UTF-8.
+func DecodeANSI(_r goio.Reader) (*filters.AsciiColorPlane, error) {
+	data, err := goio.ReadAll(_r)
+	if err != nil {
+		return nil, err
+	}
+
+	if end := bytes.IndexByte(data, 0x1a); end >= 0 {
+		data = data[:end]
+	}
+
+	var rows [][]filters.Cell
+	var row []filters.Cell
+	var pen filters.Cell
+
+	for len(data) > 0 {
+		char, size := utf8.DecodeRune(data)
+
+		switch char {
+		case '\x1b':
+			n, err := sgr(data, &pen)
+			if err != nil {
+				return nil, err
+			}
+			data = data[n:]
+			continue
+
+		case '\r':
+
+		case '\n':
+			rows = append(rows, row)
+			row = nil
+
+		case '\t':
+			cell := pen
+			cell.Rune = ' '
+			for len(row)%8 != 7 {
+				row = append(row, cell)
+			}
+			row = append(row, cell)
+
+		default:
+			cell := pen
+			cell.Rune = char
+			row = append(row, cell)
+		}
+
+		data = data[size:]
+	}
+
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	out := filters.NewAsciiColorPlane(width, len(rows))
+	for y, row := range rows {
+		line := out.Cells[y*out.Stride : y*out.Stride+width]
+		copy(line, row)
+		for x := len(row); x < width; x++ {
+			line[x].Rune = ' '
+		}
+	}
+
+	return out, nil
+}
+
+var errEscape = errors.New("ansi: unterminated escape sequence")
+
+// sgr applies the escape sequence at the start of _data to _pen and returns
+// its length. Sequences other than SGR leave _pen unchanged.
+func sgr(_data []byte, _pen *filters.Cell) (int, error) {
+	if len(_data) < 2 {
+		return 0, errEscape
+	}
+	if _data[1] != '[' {
+		return 2, nil // two byte sequence
+	}
+
+	// CSI: parameter bytes up to a final byte in 0x40-0x7E.
+	end := 2
+	for end < len(_data) && (_data[end] < 0x40 || 0x7e < _data[end]) {
+		end++
+	}
+	if end == len(_data) {
+		return 0, errEscape
+	}
+	if _data[end] != 'm' {
+		return end + 1, nil
+	}
+
+	var params []int
+	for field := range strings.SplitSeq(string(_data[2:end]), ";") {
+		// An empty parameter stands for 0.
+		v, _ := strconv.Atoi(field)
+		params = append(params, v)
+	}
+
+	for i := 0; i < len(params); i++ {
+		switch p := params[i]; {
+		case p == 0:
+			*_pen = filters.Cell{}
+
+		case p == 1:
+			_pen.Attr |= filters.Bold
+		case p == 2:
+			_pen.Attr |= filters.Faint
+		case p == 3:
+			_pen.Attr |= filters.Italic
+		case p == 4:
+			_pen.Attr |= filters.Underline
+		case p == 5:
+			_pen.Attr |= filters.Blink
+		case p == 7:
+			_pen.Attr |= filters.Reverse
+		case p == 9:
+			_pen.Attr |= filters.Strike
+		case p == 22:
+			_pen.Attr &^= filters.Bold | filters.Faint
+		case p == 23:
+			_pen.Attr &^= filters.Italic
+		case p == 24:
+			_pen.Attr &^= filters.Underline
+		case p == 25:
+			_pen.Attr &^= filters.Blink
+		case p == 27:
+			_pen.Attr &^= filters.Reverse
+		case p == 29:
+			_pen.Attr &^= filters.Strike
+
+		case 30 <= p && p <= 37:
+			_pen.FG = filters.Palette16[p-30]
+		case 90 <= p && p <= 97:
+			_pen.FG = filters.Palette16[p-90+8]
+		case p == 39:
+			_pen.FG = color.RGBA{}
+
+		case 40 <= p && p <= 47:
+			_pen.BG = filters.Palette16[p-40]
+		case 100 <= p && p <= 107:
+			_pen.BG = filters.Palette16[p-100+8]
+		case p == 49:
+			_pen.BG = color.RGBA{}
+
+		case p == 38 || p == 48:
+			c, n := extendedColor(params[i+1:])
+			i += n
+			if n == 0 {
+				break
+			}
+			if p == 38 {
+				_pen.FG = c
+			} else {
+				_pen.BG = c
+			}
+		}
+	}
+
+	return end + 1, nil
+}
+
+// extendedColor parses the parameters following 38 or 48, either 5;n or
+// 2;r;g;b, and returns the color and the number of parameters used.
+func extendedColor(_params []int) (color.RGBA, int) {
+	switch {
+	case len(_params) >= 2 && _params[0] == 5:
+		return filters.Color256(uint8(_params[1])), 2
+
+	case len(_params) >= 4 && _params[0] == 2:
+		return color.RGBA{uint8(_params[1]), uint8(_params[2]), uint8(_params[3]), 0xff}, 4
+	}
+
+	return color.RGBA{}, 0
+}
+
+// ReadArt reads a render saved as .txt, .ans or in the native format.
+// Stdio reads from the standard input, the native format being recognized
+// by its content.
+func ReadArt(_path string) (*filters.AsciiColorPlane, Metadata, error) {
+	file, err := open(_path)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if first, _ := reader.Peek(1); len(first) == 1 && first[0] == '{' {
+		return DecodeNative(reader)
+	}
+
+	plane, err := DecodeANSI(reader)
+	return plane, Metadata{}, err
+}
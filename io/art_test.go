@@ -0,0 +1,152 @@
+package io_test
+
+import (
+	"bytes"
+	"image/color"
+	goio "io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+)
+
+func TestDecodeANSI(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 0xff}
+	orange := color.RGBA{255, 128, 0, 0xff}
+
+	src := "\x1b[38;2;255;128;0mA\x1b[1;41mB\x1b[0mC\r\n" +
+		"\x1b[38;5;196;48;5;232mD\x1b[22;39;49;7mE\x1b[2Jx\tF\n" +
+		"\x1b[91mG\x1a SAUCE00 record"
+
+	plane, err := io.DecodeANSI(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plane.Width != 9 || plane.Height != 3 {
+		t.Fatalf("plane is %dx%d, want 9x3", plane.Width, plane.Height)
+	}
+
+	for _, tt := range []struct {
+		x, y int
+		want filters.Cell
+	}{
+		{0, 0, filters.Cell{Rune: 'A', FG: orange}},
+		{1, 0, filters.Cell{Rune: 'B', FG: orange, BG: filters.Palette16[1], Attr: filters.Bold}},
+		{2, 0, filters.Cell{Rune: 'C'}},
+		{3, 0, filters.Cell{Rune: ' '}}, // padding
+		{0, 1, filters.Cell{Rune: 'D', FG: red, BG: color.RGBA{8, 8, 8, 0xff}}},
+		{1, 1, filters.Cell{Rune: 'E', Attr: filters.Reverse}},
+		{2, 1, filters.Cell{Rune: 'x', Attr: filters.Reverse}},
+		{7, 1, filters.Cell{Rune: ' ', Attr: filters.Reverse}}, // tab
+		{8, 1, filters.Cell{Rune: 'F', Attr: filters.Reverse}},
+		// The pen carries over to the next line.
+		{0, 2, filters.Cell{Rune: 'G', FG: filters.Palette16[9], Attr: filters.Reverse}},
+		{1, 2, filters.Cell{Rune: ' '}},
+	} {
+		if got := plane.At(tt.x, tt.y); got != tt.want {
+			t.Errorf("cell (%d, %d) is %+v, want %+v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestArtRoundTrip(t *testing.T) {
+	plane := colored(t, "a⣿ c", color.RGBA{1, 2, 3, 0xff}, color.RGBA{250, 128, 0, 0xff})
+	plane.Cells[2].BG = color.RGBA{9, 9, 9, 0xff}
+	plane.Cells[3].Attr = filters.Italic | filters.Underline
+
+	t.Run("ansi", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := io.EncodeANSI(&buf, plane, filters.Encoder{}); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := io.DecodeANSI(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for x := range plane.Width {
+			if got.At(x, 0) != plane.At(x, 0) {
+				t.Errorf("cell %d is %+v, want %+v", x, got.At(x, 0), plane.At(x, 0))
+			}
+		}
+	})
+
+	t.Run("native", func(t *testing.T) {
+		meta := io.Metadata{Source: "in.png", Width: 640, Height: 480, Pipeline: "braille 200", Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+		var buf bytes.Buffer
+		if err := io.EncodeNative(&buf, plane, meta); err != nil {
+			t.Fatal(err)
+		}
+
+		got, gotMeta, err := io.DecodeNative(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if gotMeta != meta {
+			t.Errorf("metadata is %+v, want %+v", gotMeta, meta)
+		}
+		for x := range plane.Width {
+			if got.At(x, 0) != plane.At(x, 0) {
+				t.Errorf("cell %d is %+v, want %+v", x, got.At(x, 0), plane.At(x, 0))
+			}
+		}
+	})
+}
+
+func TestDecodeNativeInvalid(t *testing.T) {
+	for _, doc := range []string{
+		`{"format":"goscii","version":1,"width":2,"height":1,"cells":[[32,0,0,0]]}`,
+		`{"format":"goscii","version":1,"width":-1,"height":-1,"cells":[[32,0,0,0]]}`,
+		`{"format":"goscii","version":1,"width":0,"height":0,"cells":[]}`,
+		// The product of these wraps around to 0.
+		`{"format":"goscii","version":1,"width":4294967296,"height":4294967296,"cells":[]}`,
+		`{"format":"goscii","version":1,"width":65536,"height":65536,"cells":[]}`,
+		`{"format":"other","version":1,"width":1,"height":1,"cells":[[32,0,0,0]]}`,
+	} {
+		if _, _, err := io.DecodeNative(strings.NewReader(doc)); err == nil {
+			t.Errorf("DecodeNative accepted %s", doc)
+		}
+	}
+}
+
+func TestReadArtStdin(t *testing.T) {
+	plane := filters.NewAsciiColorPlane(3, 1)
+	for i := range plane.Cells {
+		plane.Cells[i].Rune = 'a' + rune(i)
+	}
+
+	path := filepath.Join(t.TempDir(), "render")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := io.EncodeNative(file, plane, io.Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+	file.Seek(0, goio.SeekStart)
+	defer file.Close()
+
+	// The standard input is only read once by a process, by this test.
+	stdin := os.Stdin
+	os.Stdin = file
+	defer func() { os.Stdin = stdin }()
+
+	if !io.IsArt(io.Stdio) {
+		t.Fatal("render on stdin is not recognized")
+	}
+	got, _, err := io.ReadArt(io.Stdio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Width != 3 || got.At(2, 0).Rune != 'c' {
+		t.Errorf("read %dx%d render ending with %q", got.Width, got.Height, got.At(2, 0).Rune)
+	}
+}
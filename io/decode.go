@@ -0,0 +1,146 @@
+package io
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// maxPixels bounds the size of the images decoded from headers that give it
+// before any pixel, rejecting those that could not be allocated.
+const maxPixels = 1 << 27
+
+// checkDimensions reports dimensions that are not positive or whose area
+// exceeds maxPixels.
+func checkDimensions(_width, _height int) error {
+	if _width <= 0 || _height <= 0 {
+		return fmt.Errorf("invalid dimensions %dx%d", _width, _height)
+	}
+	if _width > maxPixels/_height {
+		return fmt.Errorf("dimensions %dx%d exceed %d pixels", _width, _height, maxPixels)
+	}
+
+	return nil
+}
+
+// FromImage converts an image.Image into an RGBAPlane with straight
+// (non-premultiplied) alpha, the origin of the plane being _img.Bounds().Min.
+//
+// The concrete types produced by the standard decoders (*image.RGBA,
+// *image.NRGBA, *image.YCbCr, *image.Gray and *image.Paletted) are read
+// directly from their pixel buffers. Any other image goes through
+// color.NRGBAModel one pixel at a time, which is much slower.
+func FromImage(_img image.Image) *filters.RGBAPlane {
+	bounds := _img.Bounds()
+	out := filters.NewRGBAPlane(bounds.Dx(), bounds.Dy())
+
+	switch img := _img.(type) {
+	case *image.NRGBA:
+		for y := range out.Height {
+			src := img.Pix[img.PixOffset(bounds.Min.X, bounds.Min.Y+y):][:out.Width*4]
+			dst := out.RGBA[y*out.Stride:][:out.Width*4]
+
+			for i, v := range src {
+				dst[i] = float64(v)
+			}
+		}
+
+	case *image.RGBA:
+		for y := range out.Height {
+			src := img.Pix[img.PixOffset(bounds.Min.X, bounds.Min.Y+y):][:out.Width*4]
+			dst := out.RGBA[y*out.Stride:][:out.Width*4]
+
+			for i := 0; i < len(src); i += 4 {
+				a := uint32(src[i+3])
+				dst[i] = unpremultiply(src[i], a)
+				dst[i+1] = unpremultiply(src[i+1], a)
+				dst[i+2] = unpremultiply(src[i+2], a)
+				dst[i+3] = float64(a)
+			}
+		}
+
+	case *image.Gray:
+		for y := range out.Height {
+			src := img.Pix[img.PixOffset(bounds.Min.X, bounds.Min.Y+y):][:out.Width]
+			dst := out.RGBA[y*out.Stride:][:out.Width*4]
+
+			for x, v := range src {
+				dst[x*4] = float64(v)
+				dst[x*4+1] = float64(v)
+				dst[x*4+2] = float64(v)
+				dst[x*4+3] = 0xff
+			}
+		}
+
+	case *image.YCbCr:
+		for y := range out.Height {
+			dst := out.RGBA[y*out.Stride:][:out.Width*4]
+
+			for x := range out.Width {
+				yi := img.YOffset(bounds.Min.X+x, bounds.Min.Y+y)
+				ci := img.COffset(bounds.Min.X+x, bounds.Min.Y+y)
+				r, g, b := color.YCbCrToRGB(img.Y[yi], img.Cb[ci], img.Cr[ci])
+
+				dst[x*4] = float64(r)
+				dst[x*4+1] = float64(g)
+				dst[x*4+2] = float64(b)
+				dst[x*4+3] = 0xff
+			}
+		}
+
+	case *image.Paletted:
+		// Convert the palette once, then index it.
+		palette := make([][4]float64, len(img.Palette))
+		for i, c := range img.Palette {
+			palette[i] = straight(c)
+		}
+
+		for y := range out.Height {
+			src := img.Pix[img.PixOffset(bounds.Min.X, bounds.Min.Y+y):][:out.Width]
+			dst := out.RGBA[y*out.Stride:][:out.Width*4]
+
+			for x, index := range src {
+				// Out of range indices are opaque black, like image.Paletted.At.
+				c := [4]float64{0, 0, 0, 0xff}
+				if int(index) < len(palette) {
+					c = palette[index]
+				}
+				copy(dst[x*4:x*4+4], c[:])
+			}
+		}
+
+	default:
+		for y := range out.Height {
+			dst := out.RGBA[y*out.Stride:][:out.Width*4]
+
+			for x := range out.Width {
+				c := straight(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+				copy(dst[x*4:x*4+4], c[:])
+			}
+		}
+	}
+
+	return out
+}
+
+// unpremultiply returns the straight value of the 8 bit premultiplied sample
+// _c of alpha _a, rounded the same way as color.NRGBAModel.
+func unpremultiply(_c uint8, _a uint32) float64 {
+	switch _a {
+	case 0:
+		return 0
+	case 0xff:
+		return float64(_c)
+	}
+
+	c, a := uint32(_c)*0x101, _a*0x101
+	return float64((c * 0xffff / a) >> 8)
+}
+
+// straight converts any color to its straight 8 bit samples.
+func straight(_c color.Color) [4]float64 {
+	c := color.NRGBAModel.Convert(_c).(color.NRGBA)
+	return [4]float64{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+}
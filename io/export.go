@@ -0,0 +1,281 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	goio "io"
+	"path/filepath"
+	"strings"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Cells is an ASCII render whose cells can be read back, implemented by
+// filters.AsciiPlane and filters.AsciiColorPlane.
+type Cells = filters.Cells
+
+// ExportOptions are the default colors of exported renders, used for the
+// cells that carry no color of their own.
+type ExportOptions struct {
+	Foreground, Background color.RGBA
+
+	// Metadata is recorded by the native format.
+	Metadata Metadata
+
+	// Image encodes the rasterized render.
+	Image WriteOptions
+}
+
+// colors returns the colors _cell is displayed with.
+func (this ExportOptions) colors(_cell filters.Cell) (fg, bg color.RGBA) {
+	fg, bg = _cell.FG, _cell.BG
+	if fg.A == 0 {
+		fg = this.Foreground
+	}
+	if bg.A == 0 {
+		bg = this.Background
+	}
+
+	if _cell.Attr&filters.Reverse != 0 {
+		fg, bg = bg, fg
+	}
+
+	return fg, bg
+}
+
+// DefaultExportOptions is light text on a dark background, like most
+// terminals.
+var DefaultExportOptions = ExportOptions{
+	Foreground: color.RGBA{0xcc, 0xcc, 0xcc, 0xff},
+	Background: color.RGBA{0x00, 0x00, 0x00, 0xff},
+}
+
+// face is the embedded monospace font renders are rasterized with.
+var face = basicfont.Face7x13
+
+const (
+	cellWidth  = 7
+	cellHeight = 13
+)
+
+// Rasterize draws _cells with an embedded 7x13 bitmap font, one cell per
+// character.
+//
+// Braille patterns (U+2800 to U+28FF) are not part of the font and are drawn
+// as dots. Other characters missing from the font are drawn as '?'.
+func Rasterize(_cells Cells, _opts ExportOptions) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, _cells.Width_()*cellWidth, _cells.Height_()*cellHeight))
+	draw.Draw(out, out.Bounds(), image.NewUniform(_opts.Background), image.Point{}, draw.Src)
+
+	drawer := font.Drawer{Dst: out, Face: face}
+
+	for y := range _cells.Height_() {
+		for x := range _cells.Width_() {
+			char := _cells.At(x, y).Rune
+			fg, bg := _opts.colors(_cells.At(x, y))
+
+			ink := image.NewUniform(fg)
+			cell := image.Rect(x*cellWidth, y*cellHeight, (x+1)*cellWidth, (y+1)*cellHeight)
+
+			if bg != _opts.Background {
+				draw.Draw(out, cell, image.NewUniform(bg), image.Point{}, draw.Src)
+			}
+
+			if 0x2800 <= char && char <= 0x28ff {
+				drawBraille(out, cell, uint8(char-0x2800), ink)
+				continue
+			}
+
+			if char == ' ' || char == 0 {
+				continue
+			}
+			if _, ok := face.GlyphAdvance(char); !ok {
+				char = '?'
+			}
+
+			drawer.Src = ink
+			drawer.Dot = fixed.P(cell.Min.X, cell.Min.Y+face.Ascent)
+			drawer.DrawString(string(char))
+		}
+	}
+
+	return out
+}
+
+// brailleDots are the offsets of the eight dots of a Braille pattern within
+// a cell, in the bit order of Unicode.
+var brailleDots = [8]image.Point{
+	{1, 1}, {1, 4}, {1, 7}, {4, 1}, {4, 4}, {4, 7}, {1, 10}, {4, 10},
+}
+
+// drawBraille draws the 2x2 dots set in _pattern.
+func drawBraille(_dst draw.Image, _cell image.Rectangle, _pattern uint8, _ink image.Image) {
+	for bit, dot := range brailleDots {
+		if _pattern&(1<<bit) != 0 {
+			corner := _cell.Min.Add(dot)
+			draw.Draw(_dst, image.Rectangle{Min: corner, Max: corner.Add(image.Pt(2, 2))}, _ink, image.Point{}, draw.Src)
+		}
+	}
+}
+
+// Coverage returns the fraction of the pixels of a cell _char inks once
+// rasterized, from 0 for a blank to 1 for a full block. It measures how dark
+// characters look, to order palettes.
+func Coverage(_char rune) float64 {
+	ascii := filters.NewAsciiPlane(1, 1)
+	ascii.Chars[0] = _char
+
+	img := Rasterize(ascii, ExportOptions{Foreground: color.RGBA{0xff, 0xff, 0xff, 0xff}, Background: color.RGBA{0, 0, 0, 0xff}})
+
+	inked := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] > 0x7f {
+			inked++
+		}
+	}
+
+	return float64(inked) / float64(cellWidth*cellHeight)
+}
+
+// EncodeASCIIPNG writes the rasterization of _cells to _w as a PNG image.
+func EncodeASCIIPNG(_w goio.Writer, _cells Cells, _opts ExportOptions) error {
+	return png.Encode(_w, Rasterize(_cells, _opts))
+}
+
+// EncodeSVG writes _cells to _w as an SVG document, with one <text> element
+// per row and one <tspan> per run of characters of the same colors. Cells
+// with a background of their own get a <rect> per run as well.
+func EncodeSVG(_w goio.Writer, _cells Cells, _opts ExportOptions) error {
+	w := bufio.NewWriter(_w)
+	width, height := _cells.Width_()*cellWidth, _cells.Height_()*cellHeight
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(_opts.Background))
+
+	lines := runs(_cells, _opts, 0)
+	for y, line := range lines {
+		x := 0
+		for _, r := range line {
+			if r.bg != _opts.Background {
+				fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x*cellWidth, y*cellHeight, r.length*cellWidth, cellHeight, hex(r.bg))
+			}
+			x += r.length
+		}
+	}
+
+	fmt.Fprintf(w, `<g font-family="monospace" font-size="%d" xml:space="preserve">`+"\n", cellHeight-1)
+	for y, line := range lines {
+		fmt.Fprintf(w, `<text x="0" y="%d" textLength="%d">`, y*cellHeight+face.Ascent, width)
+		for _, r := range line {
+			fmt.Fprintf(w, `<tspan fill="%s">`, hex(r.fg))
+			xmlEscaper.WriteString(w, r.text)
+			w.WriteString("</tspan>")
+		}
+		w.WriteString("</text>\n")
+	}
+
+	w.WriteString("</g>\n</svg>\n")
+	return w.Flush()
+}
+
+// run is a sequence of characters of the same colors.
+type run struct {
+	text   string
+	length int // in characters
+	fg, bg color.RGBA
+}
+
+// runs splits every row of _cells into runs, after quantizing the colors of
+// the cells to _levels levels per channel.
+func runs(_cells Cells, _opts ExportOptions, _levels int) [][]run {
+	lines := make([][]run, _cells.Height_())
+
+	var text strings.Builder
+	for y := range _cells.Height_() {
+		var current run
+		for x := range _cells.Width_() {
+			cell := _cells.At(x, y)
+			cell.FG, cell.BG = quantize(cell.FG, _levels), quantize(cell.BG, _levels)
+			fg, bg := _opts.colors(cell)
+
+			char := cell.Rune
+			if char == 0 {
+				char = ' '
+			}
+
+			if x > 0 && (fg != current.fg || bg != current.bg) {
+				current.text = text.String()
+				lines[y] = append(lines[y], current)
+				text.Reset()
+				current.length = 0
+			}
+
+			current.fg, current.bg = fg, bg
+			current.length++
+			text.WriteRune(char)
+		}
+
+		if text.Len() > 0 {
+			current.text = text.String()
+			lines[y] = append(lines[y], current)
+			text.Reset()
+		}
+	}
+
+	return lines
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// hex formats the RGB part of _c as #rrggbb.
+func hex(_c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", _c.R, _c.G, _c.B)
+}
+
+// WriteASCII writes _cells to _path in the format given by its extension:
+// .txt, .ans (in true color), .json (the native format), .svg, .html for a
+// standalone page, and otherwise as an image rasterized by Rasterize and
+// encoded as described by _opts.Image. Stdio writes a PNG to the standard
+// output.
+//
+// Files are written atomically: a failed write leaves _path untouched.
+func WriteASCII(_path string, _cells Cells, _opts ExportOptions) error {
+	return writeFile(_path, func(_w goio.Writer) error {
+		switch strings.ToLower(filepath.Ext(_path)) {
+		case ".txt":
+			return EncodeText(_w, _cells)
+
+		case ".ans":
+			return EncodeANSI(_w, _cells, filters.Encoder{Profile: filters.TrueColor})
+
+		case ".json":
+			return EncodeNative(_w, _cells, _opts.Metadata)
+
+		case ".svg":
+			return EncodeSVG(_w, _cells, _opts)
+
+		case ".html", ".htm":
+			return EncodeHTML(_w, _cells, HTMLOptions{
+				ExportOptions: _opts,
+				Levels:        8,
+				Standalone:    true,
+				Title:         filepath.Base(_path),
+			})
+		}
+
+		image := _opts.Image
+		if image.Format == "" {
+			image.Format = FormatOf(_path)
+		}
+
+		return Encode(_w, Rasterize(_cells, _opts), image)
+	})
+}
@@ -0,0 +1,96 @@
+package io_test
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+)
+
+// colored returns a one row AsciiColorPlane of chars, each of its color.
+func colored(t *testing.T, chars string, colors ...color.RGBA) *filters.AsciiColorPlane {
+	t.Helper()
+
+	ascii := filters.NewAsciiPlane(len([]rune(chars)), 1)
+	copy(ascii.Chars, []rune(chars))
+
+	rgba := filters.NewRGBAPlane(ascii.Width, 1)
+	for i, c := range colors {
+		rgba.RGBA[i*4], rgba.RGBA[i*4+1], rgba.RGBA[i*4+2], rgba.RGBA[i*4+3] = float64(c.R), float64(c.G), float64(c.B), 255
+	}
+
+	out, err := ascii.Colorize(rgba)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return out
+}
+
+func TestRasterize(t *testing.T) {
+	red, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+	// A full Braille cell, then one with only its top left dot.
+	img := io.Rasterize(colored(t, "⣿⠁", red, blue), io.DefaultExportOptions)
+
+	if size := img.Bounds().Size(); size.X != 14 || size.Y != 13 {
+		t.Fatalf("image is %v, want 14x13", size)
+	}
+
+	for _, tt := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{1, 1, red}, {5, 11, red}, {0, 0, io.DefaultExportOptions.Background},
+		{8, 1, blue}, {11, 1, io.DefaultExportOptions.Background},
+	} {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("pixel (%d, %d) is %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeSVG(t *testing.T) {
+	red, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+
+	var buf bytes.Buffer
+	if err := io.EncodeSVG(&buf, colored(t, "a<b", red, red, blue), io.DefaultExportOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<tspan fill="#ff0000">a&lt;</tspan><tspan fill="#0000ff">b</tspan>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("SVG does not contain %s:\n%s", want, buf.String())
+	}
+}
+
+func TestEncodeHTML(t *testing.T) {
+	red, darkRed, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0xf0, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+	cells := colored(t, "a&bc", red, darkRed, blue, blue)
+
+	var buf bytes.Buffer
+	if err := io.EncodeHTML(&buf, cells, io.HTMLOptions{ExportOptions: io.DefaultExportOptions}); err != nil {
+		t.Fatal(err)
+	}
+	want := `<span style="color:#ff0000">a</span><span style="color:#f00000">&amp;</span><span style="color:#0000ff">bc</span>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("HTML does not contain %s:\n%s", want, buf.String())
+	}
+
+	// Quantized, both reds fall in the same class and merge.
+	buf.Reset()
+	if err := io.EncodeHTML(&buf, cells, io.HTMLOptions{ExportOptions: io.DefaultExportOptions, Levels: 4, Standalone: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		".goscii .cff0000{color:#ff0000}",
+		`<span class="cff0000">a&amp;</span><span class="c0000ff">bc</span>`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("HTML does not contain %s:\n%s", want, buf.String())
+		}
+	}
+}
@@ -1,18 +1,349 @@
 package io
 
-import "flag"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	goio "io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func CreateBoolFlag(_ptr *bool, _name string, _value bool, _desc string) {
-	flag.BoolVar(_ptr, _name, _value, _desc)
-	flag.BoolVar(_ptr, string(_name[0]), _value, _desc)
+// Flags is a set of command line flags with explicit one letter aliases.
+//
+// Flags take their value from, in increasing order of precedence, their
+// default, a config file, environment variables and the command line.
+// Registering a name or an alias twice is reported by Parse rather than
+// panicking, so that collisions show up as soon as the program starts.
+type Flags struct {
+	set   *flag.FlagSet
+	flags []*flagInfo
+	err   error
+
+	// layer counts the sources Parse went through, so that list flags set by
+	// a source replace the values of the previous ones.
+	layer int
+}
+
+// flagInfo describes a registered flag for the usage message.
+type flagInfo struct {
+	name, short, kind, desc string
+	value                   flag.Value
+	def                     string
+}
+
+// NewFlags returns an empty set of flags for the command called _name.
+// _usage, printed by -h before the flags, describes the command.
+func NewFlags(_name, _usage string) *Flags {
+	this := &Flags{set: flag.NewFlagSet(_name, flag.ContinueOnError)}
+
+	this.set.Usage = func() {
+		fmt.Fprintln(this.set.Output(), _usage)
+		fmt.Fprintln(this.set.Output())
+		this.PrintDefaults()
+	}
+
+	return this
+}
+
+// Args returns the arguments left after the flags.
+func (this *Flags) Args() []string { return this.set.Args() }
+
+// SetOutput sets where the usage and errors are printed, stderr by default.
+func (this *Flags) SetOutput(_w goio.Writer) { this.set.SetOutput(_w) }
+
+// Lookup returns the value of the flag called _name, or nil.
+func (this *Flags) Lookup(_name string) flag.Value {
+	if f := this.set.Lookup(_name); f != nil {
+		return f.Value
+	}
+
+	return nil
+}
+
+// register defines _value as _name and _short, which may be empty.
+func (this *Flags) register(_value flag.Value, _name, _short, _kind, _desc string) {
+	for _, name := range []string{_name, _short} {
+		if name == "" {
+			continue
+		}
+
+		if i := slices.IndexFunc(this.flags, func(f *flagInfo) bool { return f.name == name || f.short == name }); i >= 0 {
+			this.err = errors.Join(this.err, fmt.Errorf("%s: flag -%s of -%s is already used by -%s", this.set.Name(), name, _name, this.flags[i].name))
+			return
+		}
+	}
+
+	this.set.Var(_value, _name, _desc)
+	if _short != "" {
+		this.set.Var(_value, _short, _desc)
+	}
+
+	this.flags = append(this.flags, &flagInfo{name: _name, short: _short, kind: _kind, desc: _desc, value: _value, def: _value.String()})
+}
+
+// Bool defines a boolean flag.
+func (this *Flags) Bool(_ptr *bool, _name, _short string, _value bool, _desc string) {
+	*_ptr = _value
+	this.register((*boolValue)(_ptr), _name, _short, "", _desc)
 }
 
-func CreateIntFlag(_ptr *int, _name string, _value int, _desc string) {
-	flag.IntVar(_ptr, _name, _value, _desc)
-	flag.IntVar(_ptr, string(_name[0]), _value, _desc)
+// Int defines an integer flag.
+func (this *Flags) Int(_ptr *int, _name, _short string, _value int, _desc string) {
+	*_ptr = _value
+	this.register((*intValue)(_ptr), _name, _short, "int", _desc)
+}
+
+// Float defines a floating point flag.
+func (this *Flags) Float(_ptr *float64, _name, _short string, _value float64, _desc string) {
+	*_ptr = _value
+	this.register((*floatValue)(_ptr), _name, _short, "float", _desc)
+}
+
+// Duration defines a flag holding a duration such as 1.5s or 300ms.
+func (this *Flags) Duration(_ptr *time.Duration, _name, _short string, _value time.Duration, _desc string) {
+	*_ptr = _value
+	this.register((*durationValue)(_ptr), _name, _short, "duration", _desc)
+}
+
+// String defines a string flag.
+func (this *Flags) String(_ptr *string, _name, _short, _value, _desc string) {
+	*_ptr = _value
+	this.register((*stringValue)(_ptr), _name, _short, "string", _desc)
+}
+
+// Enum defines a string flag restricted to _choices. The default _value
+// need not be one of them, to stand for an unset flag.
+func (this *Flags) Enum(_ptr *string, _name, _short, _value string, _choices []string, _desc string) {
+	*_ptr = _value
+	this.register(&enumValue{_ptr, _choices}, _name, _short, strings.Join(_choices, "|"), _desc)
+}
+
+// List defines a repeatable flag, every occurrence adding a value to the list.
+func (this *Flags) List(_ptr *[]string, _name, _short string, _desc string) {
+	this.register(&listValue{ptr: _ptr, flags: this}, _name, _short, "value", _desc+" (repeatable)")
+}
+
+// Map defines a repeatable key=value flag.
+func (this *Flags) Map(_ptr *map[string]string, _name, _short string, _desc string) {
+	this.register(&mapValue{ptr: _ptr}, _name, _short, "key=value", _desc+" (repeatable)")
+}
+
+// PrintDefaults prints the flags along with their aliases and defaults.
+func (this *Flags) PrintDefaults() {
+	w := this.set.Output()
+	fmt.Fprintln(w, "flags:")
+
+	for _, f := range this.flags {
+		names := "-" + f.name
+		if f.short != "" {
+			names = "-" + f.short + ", " + names
+		}
+		if f.kind != "" {
+			names += " " + f.kind
+		}
+
+		fmt.Fprintf(w, "  %s\n    \t%s", names, f.desc)
+		switch {
+		case f.def == "" || f.def == "false" || f.def == "0":
+		case f.kind == "int" || f.kind == "float" || f.kind == "duration":
+			fmt.Fprintf(w, " (default %s)", f.def)
+		default:
+			fmt.Fprintf(w, " (default %q)", f.def)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// Parse sets the flags from _config, values by flag name, then from the
+// environment variables named _env, an underscore and the name of the flag in
+// upper case with dashes as underscores, then from _args.
+//
+// Keys of _config and environment variables naming no flag are ignored, as
+// they may be meant for another command. Only repeatable flags take several
+// values from _config, and comma separated ones from the environment.
+//
+// A source setting a list flag replaces the values of the previous ones,
+// -exclude '*.gif' on the command line dropping the patterns of the config.
+// Map flags instead keep the pairs of every source, each source replacing the
+// values of the keys it sets.
+func (this *Flags) Parse(_args []string, _config map[string][]string, _env string) error {
+	if this.err != nil {
+		return this.err
+	}
+
+	this.layer++
+	for _, f := range this.flags {
+		values := _config[f.name]
+		if _, ok := f.value.(repeatable); !ok && len(values) > 1 {
+			return fmt.Errorf("config: %s: expected a single value", f.name)
+		}
+
+		for _, value := range values {
+			if err := f.value.Set(value); err != nil {
+				return fmt.Errorf("config: %s: %w", f.name, err)
+			}
+		}
+	}
+
+	this.layer++
+	for _, f := range this.flags {
+		if _env == "" {
+			break
+		}
+
+		key := _env + "_" + strings.ToUpper(strings.ReplaceAll(f.name, "-", "_"))
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		values := []string{value}
+		if _, ok := f.value.(repeatable); ok {
+			values = strings.Split(value, ",")
+		}
+		for _, value := range values {
+			if err := f.value.Set(value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+
+	this.layer++
+	err := this.set.Parse(_args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return ErrUsage
+	}
+
+	return err
+}
+
+// ErrUsage is returned by Parse for command lines the flags rejected, after
+// printing why along with the usage.
+var ErrUsage = errors.New("invalid command line")
+
+type boolValue bool
+
+func (this *boolValue) Set(_s string) error {
+	v, err := strconv.ParseBool(_s)
+	*this = boolValue(v)
+	return err
+}
+func (this *boolValue) String() string   { return strconv.FormatBool(bool(*this)) }
+func (this *boolValue) IsBoolFlag() bool { return true }
+
+type intValue int
+
+func (this *intValue) Set(_s string) error {
+	v, err := strconv.Atoi(_s)
+	if err != nil {
+		return fmt.Errorf("not an integer: %q", _s)
+	}
+	*this = intValue(v)
+	return nil
+}
+func (this *intValue) String() string { return strconv.Itoa(int(*this)) }
+
+type floatValue float64
+
+func (this *floatValue) Set(_s string) error {
+	v, err := strconv.ParseFloat(_s, 64)
+	if err != nil {
+		return fmt.Errorf("not a number: %q", _s)
+	}
+	*this = floatValue(v)
+	return nil
+}
+func (this *floatValue) String() string { return strconv.FormatFloat(float64(*this), 'g', -1, 64) }
+
+type durationValue time.Duration
+
+func (this *durationValue) Set(_s string) error {
+	v, err := time.ParseDuration(_s)
+	*this = durationValue(v)
+	return err
+}
+func (this *durationValue) String() string { return time.Duration(*this).String() }
+
+type stringValue string
+
+func (this *stringValue) Set(_s string) error { *this = stringValue(_s); return nil }
+func (this *stringValue) String() string      { return string(*this) }
+
+type enumValue struct {
+	ptr     *string
+	choices []string
+}
+
+func (this *enumValue) Set(_s string) error {
+	if !slices.Contains(this.choices, _s) {
+		return fmt.Errorf("%q is not one of %s", _s, strings.Join(this.choices, ", "))
+	}
+	*this.ptr = _s
+	return nil
+}
+func (this *enumValue) String() string {
+	if this.ptr == nil {
+		return ""
+	}
+	return *this.ptr
+}
+
+// repeatable is implemented by the values of flags that can be repeated.
+type repeatable interface{ repeatable() }
+
+// listValue appends its values, replacing the values of previous sources.
+type listValue struct {
+	ptr   *[]string
+	flags *Flags
+	layer int
+}
+
+func (this *listValue) Set(_s string) error {
+	if this.layer != this.flags.layer {
+		*this.ptr, this.layer = nil, this.flags.layer
+	}
+	*this.ptr = append(*this.ptr, _s)
+	return nil
+}
+func (this *listValue) String() string {
+	if this.ptr == nil {
+		return ""
+	}
+	return strings.Join(*this.ptr, ",")
+}
+func (this *listValue) repeatable() {}
+
+// mapValue adds its key=value pairs to those of previous sources.
+type mapValue struct {
+	ptr *map[string]string
+}
+
+func (this *mapValue) Set(_s string) error {
+	key, value, ok := strings.Cut(_s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", _s)
+	}
+
+	if *this.ptr == nil {
+		*this.ptr = map[string]string{}
+	}
+	(*this.ptr)[key] = value
+	return nil
 }
+func (this *mapValue) String() string {
+	if this.ptr == nil {
+		return ""
+	}
 
-func CreateStringFlag(_ptr *string, _name, _value, _desc string) {
-	flag.StringVar(_ptr, _name, _value, _desc)
-	flag.StringVar(_ptr, string(_name[0]), _value, _desc)
+	pairs := make([]string, 0, len(*this.ptr))
+	for key, value := range *this.ptr {
+		pairs = append(pairs, key+"="+value)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
 }
+func (this *mapValue) repeatable() {}
@@ -0,0 +1,161 @@
+package io_test
+
+import (
+	"errors"
+	goio "io"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/io"
+)
+
+func TestFlagsCollision(t *testing.T) {
+	var in, invert string
+
+	flags := io.NewFlags("test", "")
+	flags.String(&in, "in", "i", "", "")
+	flags.String(&invert, "invert", "i", "", "")
+
+	if err := flags.Parse(nil, nil, ""); err == nil {
+		t.Fatal("colliding aliases were accepted")
+	}
+
+	flags = io.NewFlags("test", "")
+	flags.String(&in, "in", "i", "", "")
+	flags.String(&invert, "invert", "v", "", "")
+
+	if err := flags.Parse([]string{"-v", "yes", "-i", "file"}, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if in != "file" || invert != "yes" {
+		t.Errorf("in = %q, invert = %q", in, invert)
+	}
+}
+
+func TestFlagsSources(t *testing.T) {
+	config := map[string][]string{
+		"ratio": {"0.5"},
+		"mode":  {"ascii"},
+		"in":    {"config.png"},
+		"delay": {"1s"},
+	}
+
+	t.Setenv("TEST_DELAY", "250ms")
+	t.Setenv("TEST_IN", "env.png")
+
+	var ratio float64
+	var mode, in string
+	var delay time.Duration
+
+	flags := io.NewFlags("test", "")
+	flags.SetOutput(goio.Discard)
+	flags.Float(&ratio, "ratio", "r", 1, "")
+	flags.Enum(&mode, "mode", "m", "braille", []string{"braille", "ascii"}, "")
+	flags.String(&in, "in", "i", "", "")
+	flags.Duration(&delay, "delay", "d", 0, "")
+
+	if err := flags.Parse([]string{"-i", "args.png"}, config, "TEST"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The command line replaces the environment, itself replacing the config.
+	if ratio != .5 || mode != "ascii" || delay != 250*time.Millisecond {
+		t.Errorf("ratio = %v, mode = %q, delay = %v", ratio, mode, delay)
+	}
+	if in != "args.png" {
+		t.Errorf("in = %q, want args.png", in)
+	}
+
+	flags = io.NewFlags("test", "")
+	flags.SetOutput(goio.Discard)
+	flags.Enum(&mode, "mode", "m", "braille", []string{"braille", "ascii"}, "")
+	if err := flags.Parse([]string{"-m", "edges"}, nil, ""); !errors.Is(err, io.ErrUsage) {
+		t.Errorf("invalid choice gives %v, want ErrUsage", err)
+	}
+}
+
+func TestFlagsRepeatable(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config map[string][]string
+		env    map[string]string
+		args   []string
+		list   []string
+		pairs  map[string]string
+	}{
+		{
+			name:   "config",
+			config: map[string][]string{"tag": {"a", "b"}, "set": {"x=1", "y=2"}},
+			list:   []string{"a", "b"},
+			pairs:  map[string]string{"x": "1", "y": "2"},
+		},
+		{
+			name:   "environment",
+			config: map[string][]string{"tag": {"a"}, "set": {"x=1", "y=2"}},
+			env:    map[string]string{"TEST_TAG": "c,d", "TEST_SET": "y=3,z=4"},
+			list:   []string{"c", "d"},
+			pairs:  map[string]string{"x": "1", "y": "3", "z": "4"},
+		},
+		{
+			// The command line replaces the list of the other sources and
+			// the keys it sets of their map.
+			name:   "command line",
+			config: map[string][]string{"tag": {"a"}, "set": {"x=1"}},
+			env:    map[string]string{"TEST_TAG": "b", "TEST_SET": "y=2"},
+			args:   []string{"-t", "e", "-tag", "f", "-s", "x=5", "-set", "w=a=b"},
+			list:   []string{"e", "f"},
+			pairs:  map[string]string{"x": "5", "y": "2", "w": "a=b"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			var list []string
+			var pairs map[string]string
+
+			flags := io.NewFlags("test", "")
+			flags.List(&list, "tag", "t", "")
+			flags.Map(&pairs, "set", "s", "")
+			if err := flags.Parse(test.args, test.config, "TEST"); err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(list, test.list) {
+				t.Errorf("list = %q, want %q", list, test.list)
+			}
+			if !maps.Equal(pairs, test.pairs) {
+				t.Errorf("map = %v, want %v", pairs, test.pairs)
+			}
+		})
+	}
+}
+
+func TestFlagsRepeatableErrors(t *testing.T) {
+	var list []string
+	var pairs map[string]string
+	var in string
+
+	for _, test := range []struct {
+		config map[string][]string
+		args   []string
+	}{
+		{args: []string{"-set", "novalue"}},
+		{args: []string{"-set", "=1"}},
+		{config: map[string][]string{"set": {"x"}}},
+		{config: map[string][]string{"in": {"a.png", "b.png"}}},
+	} {
+		flags := io.NewFlags("test", "")
+		flags.SetOutput(goio.Discard)
+		flags.List(&list, "tag", "t", "")
+		flags.Map(&pairs, "set", "s", "")
+		flags.String(&in, "in", "i", "", "")
+
+		if err := flags.Parse(test.args, test.config, ""); err == nil {
+			t.Errorf("Parse(%q, %v) returned no error", test.args, test.config)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"iter"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// Frame is one image of an animation, shown for Delay.
+type Frame struct {
+	Image *filters.RGBAPlane
+	Delay time.Duration
+}
+
+// Animation is a sequence of frames, composited one at a time as they are
+// read so that long GIFs do not hold a full picture per frame.
+type Animation struct {
+	// Width and Height are the size of the frames.
+	Width, Height int
+
+	// LoopCount follows image/gif: 0 loops forever, -1 plays the animation
+	// once and n plays it n+1 times.
+	LoopCount int
+
+	// gif holds the frames of GIFs, still the image of other formats.
+	gif   *gif.GIF
+	still *filters.RGBAPlane
+}
+
+// minDelay is the delay given to GIF frames declaring less than 20ms, which
+// browsers treat as 100ms as well.
+const minDelay = 100 * time.Millisecond
+
+// ReadFrames decodes the animation at _path, Stdio reading the standard
+// input. Files of any other format than GIF yield a single frame with no
+// delay.
+func ReadFrames(_path string) (*Animation, error) {
+	file, err := open(_path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(4)
+	if !bytes.Equal(magic, []byte("GIF8")) {
+		img, _, err := image.Decode(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		still := FromImage(img)
+		return &Animation{Width: still.Width, Height: still.Height, LoopCount: -1, still: still}, nil
+	}
+
+	anim, err := gif.DecodeAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(anim.Image) == 0 {
+		return nil, errors.New("gif: no frame")
+	}
+
+	screen := image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+	if screen.Empty() {
+		screen = anim.Image[0].Bounds()
+	}
+
+	return &Animation{Width: screen.Dx(), Height: screen.Dy(), LoopCount: anim.LoopCount, gif: anim}, nil
+}
+
+// Len returns the number of frames.
+func (this *Animation) Len() int {
+	if this.gif == nil {
+		return 1
+	}
+
+	return len(this.gif.Image)
+}
+
+// Frames returns the frames in order, along with their index. GIF frames are
+// composited onto the logical screen according to their disposal method, so
+// that each Frame holds the full picture as it is meant to be displayed. The
+// frames are converted as they are reached and not kept.
+func (this *Animation) Frames() iter.Seq2[int, Frame] {
+	return func(_yield func(int, Frame) bool) {
+		if this.gif == nil {
+			_yield(0, Frame{Image: this.still})
+			return
+		}
+
+		composite(this.gif, image.Rect(0, 0, this.Width, this.Height), _yield)
+	}
+}
+
+// composite replays the frames of _anim onto a canvas of the size of
+// _screen, yielding each of them until _yield returns false.
+func composite(_anim *gif.GIF, _screen image.Rectangle, _yield func(int, Frame) bool) {
+	canvas := image.NewRGBA(_screen)
+	var previous *image.RGBA
+
+	for i, frame := range _anim.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(_anim.Disposal) {
+			disposal = _anim.Disposal[i]
+		}
+
+		if disposal == gif.DisposalPrevious {
+			if previous == nil {
+				previous = image.NewRGBA(_screen)
+			}
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		delay := minDelay
+		if i < len(_anim.Delay) && _anim.Delay[i] >= 2 {
+			delay = time.Duration(_anim.Delay[i]) * 10 * time.Millisecond
+		}
+		if !_yield(i, Frame{Image: FromImage(canvas), Delay: delay}) {
+			return
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			// Like browsers, clear to transparent rather than to the
+			// background color.
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, previous.Pix)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	goio "io"
+	"slices"
+)
+
+// HTMLOptions configures EncodeHTML.
+type HTMLOptions struct {
+	ExportOptions
+
+	// Levels quantizes every channel to that many levels and styles the
+	// spans with one CSS class per resulting color, which keeps pages
+	// small. Zero keeps the exact colors as inline styles.
+	Levels int
+
+	// Standalone wraps the <pre> block in a complete page titled Title.
+	Standalone bool
+	Title      string
+}
+
+// EncodeHTML writes _cells to _w as a <pre> block with one <span> per run of
+// characters of the same color.
+func EncodeHTML(_w goio.Writer, _cells Cells, _opts HTMLOptions) error {
+	w := bufio.NewWriter(_w)
+
+	lines := runs(_cells, _opts.ExportOptions, _opts.Levels)
+
+	if _opts.Standalone {
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body style=\"margin:0;background:%s\">\n",
+			html.EscapeString(_opts.Title), hex(_opts.Background))
+	}
+
+	if _opts.Levels > 0 {
+		// One class per foreground and one per background color.
+		var rules []string
+		for _, line := range lines {
+			for _, r := range line {
+				rules = append(rules, fmt.Sprintf(".goscii .%s{color:%s}", class(r.fg), hex(r.fg)))
+				if r.bg != _opts.Background {
+					rules = append(rules, fmt.Sprintf(".goscii .b%s{background:%s}", class(r.bg), hex(r.bg)))
+				}
+			}
+		}
+		slices.Sort(rules)
+
+		w.WriteString("<style>\n")
+		for _, rule := range slices.Compact(rules) {
+			w.WriteString(rule + "\n")
+		}
+		w.WriteString("</style>\n")
+	}
+
+	fmt.Fprintf(w, "<pre class=\"goscii\" style=\"background:%s;color:%s;line-height:1;font-family:monospace\">", hex(_opts.Background), hex(_opts.Foreground))
+	for y, line := range lines {
+		if y > 0 {
+			w.WriteByte('\n')
+		}
+
+		for _, r := range line {
+			switch {
+			case _opts.Levels > 0 && r.bg != _opts.Background:
+				fmt.Fprintf(w, "<span class=\"%s b%s\">", class(r.fg), class(r.bg))
+			case _opts.Levels > 0:
+				fmt.Fprintf(w, "<span class=\"%s\">", class(r.fg))
+			case r.bg != _opts.Background:
+				fmt.Fprintf(w, "<span style=\"color:%s;background:%s\">", hex(r.fg), hex(r.bg))
+			default:
+				fmt.Fprintf(w, "<span style=\"color:%s\">", hex(r.fg))
+			}
+			w.WriteString(html.EscapeString(r.text))
+			w.WriteString("</span>")
+		}
+	}
+	w.WriteString("</pre>\n")
+
+	if _opts.Standalone {
+		w.WriteString("</body>\n</html>\n")
+	}
+
+	return w.Flush()
+}
+
+// quantize rounds every channel of _c to the nearest of _levels evenly
+// spaced levels, leaving _c as is when _levels is below 2.
+func quantize(_c color.RGBA, _levels int) color.RGBA {
+	if _levels < 2 {
+		return _c
+	}
+
+	step := 255. / float64(_levels-1)
+	round := func(v uint8) uint8 {
+		return uint8(float64(int(float64(v)/step+.5))*step + .5)
+	}
+
+	return color.RGBA{round(_c.R), round(_c.G), round(_c.B), _c.A}
+}
+
+// class is the CSS class of _c.
+func class(_c color.RGBA) string {
+	return "c" + hex(_c)[1:]
+}
@@ -1,73 +1,188 @@
 package io
 
 import (
+	"fmt"
 	"image"
-	_ "image/jpeg"
+	"image/gif"
+	"image/jpeg"
 	"image/png"
-	_ "image/png"
-	"os"
+	goio "io"
+	"path/filepath"
+	"strings"
 
 	"github.com/IJJA3141/GoSCII/filters"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
 )
 
+// Read decodes the image at _path into an RGBAPlane with straight alpha.
+// Stdio reads the image from the standard input.
+//
+// The format is detected from the first bytes of the file, whatever its
+// extension: PNG, JPEG, GIF, BMP, TIFF, WebP and Netpbm are supported.
 func Read(_path string) (*filters.RGBAPlane, error) {
-	file, err := os.Open(_path)
+	file, err := open(_path)
 	if err != nil {
 		return nil, err
 	}
 
 	defer file.Close()
 
-	image, _, err := image.Decode(file)
+	img, _, err := image.Decode(file)
 	if err != nil {
 		return nil, err
 	}
 
-	out := filters.NewRGBAPlane(image.Bounds().Dx(), image.Bounds().Dy())
-	for y := range out.Height {
-		for x := range out.Width {
-			r, g, b, a := image.At(x, y).RGBA()
+	return FromImage(img), nil
+}
 
-			index := y*out.Stride + x*4
-			out.RGBA[index] = float64(r >> 8)
-			out.RGBA[index+1] = float64(g >> 8)
-			out.RGBA[index+2] = float64(b >> 8)
-			out.RGBA[index+3] = float64(a >> 8)
-		}
+// Format is an image format images can be written in.
+type Format string
+
+const (
+	PNG  Format = "png"
+	JPEG Format = "jpeg"
+	GIF  Format = "gif"
+	PPM  Format = "ppm"
+)
+
+// ParseFormat parses the name of a format, as given on the command line.
+func ParseFormat(_name string) (Format, error) {
+	switch name := Format(strings.ToLower(_name)); name {
+	case PNG, JPEG, GIF, PPM:
+		return name, nil
+
+	case "jpg":
+		return JPEG, nil
 	}
 
-	return out, nil
+	return "", fmt.Errorf("unknown image format %q", _name)
 }
 
-func Write(_path string, _img *filters.RGBAPlane) error {
-	outfile, err := os.Create(_path)
-	if err != nil {
-		return err
+// FormatOf returns the format given by the extension of _path, PNG when it
+// names none of them.
+func FormatOf(_path string) Format {
+	switch strings.ToLower(filepath.Ext(_path)) {
+	case ".jpg", ".jpeg":
+		return JPEG
+	case ".gif":
+		return GIF
+	case ".ppm":
+		return PPM
+	}
+
+	return PNG
+}
+
+// IsImage reports whether the extension of _path is one of an image format
+// Read decodes.
+func IsImage(_path string) bool {
+	switch strings.ToLower(filepath.Ext(_path)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".tif", ".tiff", ".webp", ".pbm", ".pgm", ".ppm", ".pnm":
+		return true
+	}
+
+	return false
+}
+
+// WriteOptions configures how images are encoded.
+type WriteOptions struct {
+	// Format is the format to write, given by the extension of the path
+	// when empty.
+	Format Format
+
+	// Compression is the PNG compression level.
+	Compression png.CompressionLevel
+
+	// Quality is the JPEG quality, from 1 to 100, jpeg.DefaultQuality when 0.
+	Quality int
+}
+
+// ParseCompression parses a PNG compression level: default, none, fast or
+// best.
+func ParseCompression(_name string) (png.CompressionLevel, error) {
+	switch strings.ToLower(_name) {
+	case "", "default":
+		return png.DefaultCompression, nil
+	case "none":
+		return png.NoCompression, nil
+	case "fast":
+		return png.BestSpeed, nil
+	case "best":
+		return png.BestCompression, nil
 	}
 
-	defer outfile.Close()
+	return 0, fmt.Errorf("unknown compression level %q", _name)
+}
+
+// Encode writes _img to _w in _opts.Format, PNG when it is empty.
+func Encode(_w goio.Writer, _img image.Image, _opts WriteOptions) error {
+	switch _opts.Format {
+	case "", PNG:
+		encoder := png.Encoder{CompressionLevel: _opts.Compression}
+		return encoder.Encode(_w, _img)
+
+	case JPEG:
+		quality := _opts.Quality
+		if quality == 0 {
+			quality = jpeg.DefaultQuality
+		}
+		return jpeg.Encode(_w, _img, &jpeg.Options{Quality: quality})
+
+	case GIF:
+		return gif.Encode(_w, _img, nil)
+
+	case PPM:
+		return EncodePPM(_w, FromImage(_img))
+	}
+
+	return fmt.Errorf("unknown image format %q", _opts.Format)
+}
+
+// ToImage converts _img into an image.NRGBA, truncating samples to 8 bits
+// after clamping them to [0, 255].
+func ToImage(_img *filters.RGBAPlane) *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, _img.Width, _img.Height))
 
-	pix := make([]uint8, _img.Width*_img.Height*4)
 	for y := range _img.Height {
-		for x := range _img.Width {
-			index := y*_img.Stride + x*4
+		row := _img.RGBA[y*_img.Stride : y*_img.Stride+_img.Width*4]
+		pix := out.Pix[y*out.Stride : y*out.Stride+_img.Width*4]
 
-			pix[index] = uint8(_img.RGBA[index])
-			pix[index+1] = uint8(_img.RGBA[index+1])
-			pix[index+2] = uint8(_img.RGBA[index+2])
-			pix[index+3] = uint8(_img.RGBA[index+3])
+		for i, v := range row {
+			pix[i] = sample(v)
 		}
 	}
 
-	png.Encode(outfile, &image.RGBA{
-		Pix:    pix,
-		Stride: _img.Stride,
-		Rect: image.Rectangle{
-			Min: image.Point{X: 0, Y: 0},
-			Max: image.Point{X: _img.Width, Y: _img.Height},
-		},
-	},
-	)
-
-	return nil
+	return out
+}
+
+// sample truncates _v to 8 bits after clamping it to [0, 255].
+func sample(_v float64) uint8 {
+	return uint8(min(max(_v, 0), 255))
+}
+
+// Write encodes _img to _path as described by _opts. Stdio writes to the
+// standard output, in PNG unless _opts.Format says otherwise.
+//
+// Files are written atomically: a failed write leaves _path untouched.
+func Write(_path string, _img *filters.RGBAPlane, _opts WriteOptions) error {
+	if _opts.Format == "" {
+		_opts.Format = FormatOf(_path)
+	}
+
+	return writeFile(_path, func(_w goio.Writer) error {
+		if _opts.Format == PPM {
+			return EncodePPM(_w, _img)
+		}
+
+		return Encode(_w, ToImage(_img), _opts)
+	})
+}
+
+// WritePGM writes _img to _path as a PGM image, without going through RGBA.
+func WritePGM(_path string, _img *filters.GrayScalePlane) error {
+	return writeFile(_path, func(_w goio.Writer) error {
+		return EncodePGM(_w, _img)
+	})
 }
@@ -0,0 +1,286 @@
+package io_test
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	goio "io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// opaque hides the concrete type of an image, forcing FromImage through its
+// generic path.
+type opaque struct{ image.Image }
+
+func TestFromImageFastPaths(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	rect := image.Rect(0, 0, 37, 23)
+	// A sub-image with a non-zero origin.
+	sub := image.Rect(3, 5, 30, 20)
+
+	rgba := image.NewRGBA(rect)
+	nrgba := image.NewNRGBA(rect)
+	gray := image.NewGray(rect)
+	paletted := image.NewPaletted(rect, palette.Plan9)
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+
+	for i := range rgba.Pix {
+		nrgba.Pix[i] = uint8(r.Uint32())
+	}
+	for y := range rect.Dy() {
+		for x := range rect.Dx() {
+			// Premultiplied samples never exceed alpha.
+			a := uint8(r.Uint32())
+			rgba.SetRGBA(x, y, color.RGBA{uint8(r.UintN(uint(a) + 1)), uint8(r.UintN(uint(a) + 1)), uint8(r.UintN(uint(a) + 1)), a})
+		}
+	}
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(r.Uint32())
+		paletted.Pix[i] = uint8(r.Uint32())
+	}
+	for _, plane := range [][]uint8{ycbcr.Y, ycbcr.Cb, ycbcr.Cr} {
+		for i := range plane {
+			plane[i] = uint8(r.Uint32())
+		}
+	}
+
+	tests := []struct {
+		name string
+		img  interface {
+			image.Image
+			SubImage(image.Rectangle) image.Image
+		}
+	}{
+		{"RGBA", rgba},
+		{"NRGBA", nrgba},
+		{"Gray", gray},
+		{"Paletted", paletted},
+		{"YCbCr", ycbcr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, img := range []image.Image{tt.img, tt.img.SubImage(sub)} {
+				got, want := io.FromImage(img), io.FromImage(opaque{img})
+
+				if got.Width != img.Bounds().Dx() || got.Height != img.Bounds().Dy() {
+					t.Fatalf("plane is %dx%d, want %v", got.Width, got.Height, img.Bounds().Size())
+				}
+
+				for i := range want.RGBA {
+					if got.RGBA[i] != want.RGBA[i] {
+						t.Fatalf("bounds %v: sample %d is %v, want %v", img.Bounds(), i, got.RGBA[i], want.RGBA[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFromImageUnpremultiplies(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, color.RGBA{64, 32, 0, 128})
+
+	got := io.FromImage(img).RGBA
+	if got[0] != 127 || got[1] != 63 || got[2] != 0 || got[3] != 128 {
+		t.Errorf("FromImage = %v, want [127 63 0 128]", got)
+	}
+}
+
+func TestReadFramesDisposal(t *testing.T) {
+	red, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+	pal := color.Palette{color.Transparent, red, blue}
+
+	frame := func(x0, x1 int, index uint8) *image.Paletted {
+		img := image.NewPaletted(image.Rect(x0, 0, x1, 1), pal)
+		for i := range img.Pix {
+			img.Pix[i] = index
+		}
+		return img
+	}
+
+	anim := &gif.GIF{
+		Image:     []*image.Paletted{frame(0, 4, 1), frame(1, 2, 2), frame(2, 3, 2), frame(3, 4, 2)},
+		Delay:     []int{0, 5, 50, 1},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious, gif.DisposalNone},
+		Config:    image.Config{ColorModel: pal, Width: 4, Height: 1},
+		LoopCount: 3,
+	}
+
+	path := filepath.Join(t.TempDir(), "anim.gif")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.EncodeAll(file, anim); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	got, err := io.ReadFrames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// R red, B blue, _ transparent.
+	want := []string{"RRRR", "RBRR", "R_BR", "R_RB"}
+	delays := []time.Duration{100 * time.Millisecond, 50 * time.Millisecond, 500 * time.Millisecond, 100 * time.Millisecond}
+
+	if got.Len() != len(want) || got.Width != 4 || got.Height != 1 || got.LoopCount != 3 {
+		t.Fatalf("%d frames of %dx%d looping %d times, want %d of 4x1 looping 3 times", got.Len(), got.Width, got.Height, got.LoopCount, len(want))
+	}
+
+	for i, frame := range got.Frames() {
+		var pixels strings.Builder
+		for x := range frame.Image.Width {
+			switch px := frame.Image.RGBA[x*4 : x*4+4]; {
+			case px[3] == 0:
+				pixels.WriteByte('_')
+			case px[0] == 0xff:
+				pixels.WriteByte('R')
+			case px[2] == 0xff:
+				pixels.WriteByte('B')
+			}
+		}
+
+		if pixels.String() != want[i] {
+			t.Errorf("frame %d is %s, want %s", i, pixels.String(), want[i])
+		}
+		if frame.Delay != delays[i] {
+			t.Errorf("frame %d lasts %v, want %v", i, frame.Delay, delays[i])
+		}
+	}
+
+	// Reading again composites the frames from the first one, up to where
+	// the reader stops.
+	read := 0
+	for i, frame := range got.Frames() {
+		read++
+		if i == 1 {
+			if frame.Image.RGBA[6] != 0xff {
+				t.Error("second frame read again is not composited over the first")
+			}
+			break
+		}
+	}
+	if read != 2 {
+		t.Errorf("%d frames read after stopping at the second", read)
+	}
+}
+
+func TestReadDetectsFormat(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 13)
+	}
+	for i := 3; i < len(src.Pix); i += 4 {
+		src.Pix[i] = 0xff
+	}
+
+	encoders := map[string]func(w goio.Writer, m image.Image) error{
+		"bmp":  bmp.Encode,
+		"tiff": func(w goio.Writer, m image.Image) error { return tiff.Encode(w, m, nil) },
+		"gif":  func(w goio.Writer, m image.Image) error { return gif.Encode(w, m, nil) },
+	}
+
+	for name, encode := range encoders {
+		t.Run(name, func(t *testing.T) {
+			// The extension is wrong on purpose: detection uses magic bytes.
+			path := filepath.Join(t.TempDir(), "image.png")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := encode(file, src); err != nil {
+				t.Fatal(err)
+			}
+			file.Close()
+
+			got, err := io.Read(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name == "gif" {
+				return // palette quantization changes the colors
+			}
+
+			want := io.FromImage(src)
+			for i := range want.RGBA {
+				if got.RGBA[i] != want.RGBA[i] {
+					t.Fatalf("sample %d is %v, want %v", i, got.RGBA[i], want.RGBA[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	// A 2x2 view of a 3 pixel wide buffer, with out of range samples.
+	img := &filters.RGBAPlane{
+		RGBA: []float64{
+			10, 20, 30, 255, -5, 300, 40, 255, 1, 1, 1, 1,
+			50, 60, 70, 255, 80, 90, 100, 128, 1, 1, 1, 1,
+		},
+		Width: 2, Height: 2, Stride: 12,
+	}
+	want := []uint8{10, 20, 30, 255, 0, 255, 40, 255, 50, 60, 70, 255, 80, 90, 100, 128}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.png")
+	if err := io.Write(path, img, io.WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := io.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range want {
+		if uint8(got.RGBA[i]) != v {
+			t.Fatalf("sample %d is %v, want %v", i, got.RGBA[i], v)
+		}
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		before, _ := os.ReadFile(path)
+
+		if err := io.Write(path, img, io.WriteOptions{Format: "bmp"}); err == nil {
+			t.Fatal("unknown format was accepted")
+		}
+
+		if after, _ := os.ReadFile(path); string(after) != string(before) {
+			t.Error("failed write modified the output")
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("failed write left %d files behind", len(entries)-1)
+		}
+	})
+
+	t.Run("jpeg", func(t *testing.T) {
+		path := filepath.Join(dir, "out.jpg")
+		if err := io.Write(path, img, io.WriteOptions{Quality: 100}); err != nil {
+			t.Fatal(err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		if _, format, err := image.DecodeConfig(file); err != nil || format != "jpeg" {
+			t.Errorf("output is %q (%v), want jpeg", format, err)
+		}
+	})
+}
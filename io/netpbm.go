@@ -0,0 +1,315 @@
+package io
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	goio "io"
+	"strconv"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// Netpbm images (PBM, PGM and PPM, both plain and raw) are decoded through
+// image.Decode like any other registered format.
+//
+// https://netpbm.sourceforge.net/doc/pbm.html
+func init() {
+	for _, magic := range []string{"P1", "P4"} {
+		image.RegisterFormat("pbm", magic, decodeNetpbm, decodeNetpbmConfig)
+	}
+	for _, magic := range []string{"P2", "P5"} {
+		image.RegisterFormat("pgm", magic, decodeNetpbm, decodeNetpbmConfig)
+	}
+	for _, magic := range []string{"P3", "P6"} {
+		image.RegisterFormat("ppm", magic, decodeNetpbm, decodeNetpbmConfig)
+	}
+}
+
+var errNetpbm = errors.New("netpbm: invalid format")
+
+// netpbmHeader is the header shared by every Netpbm format.
+type netpbmHeader struct {
+	magic         byte // '1' to '6'
+	width, height int
+	maxval        int
+}
+
+// readToken returns the next whitespace separated token, skipping comments.
+func readToken(_r *bufio.Reader) (string, error) {
+	var token []byte
+
+	for {
+		c, err := _r.ReadByte()
+		if err != nil {
+			if err == goio.EOF && len(token) > 0 {
+				return string(token), nil
+			}
+			return "", err
+		}
+
+		switch {
+		case c == '#' && len(token) == 0:
+			if _, err := _r.ReadString('\n'); err != nil {
+				return "", err
+			}
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f':
+			if len(token) > 0 {
+				return string(token), nil
+			}
+
+		default:
+			token = append(token, c)
+		}
+	}
+}
+
+// readNumber reads a positive decimal token.
+func readNumber(_r *bufio.Reader) (int, error) {
+	token, err := readToken(_r)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(token)
+	if err != nil || n < 0 {
+		return 0, errNetpbm
+	}
+
+	return n, nil
+}
+
+func readNetpbmHeader(_r *bufio.Reader) (netpbmHeader, error) {
+	var h netpbmHeader
+
+	magic, err := readToken(_r)
+	if err != nil {
+		return h, err
+	}
+	if len(magic) != 2 || magic[0] != 'P' || magic[1] < '1' || '6' < magic[1] {
+		return h, errNetpbm
+	}
+	h.magic = magic[1]
+
+	if h.width, err = readNumber(_r); err != nil {
+		return h, err
+	}
+	if h.height, err = readNumber(_r); err != nil {
+		return h, err
+	}
+	if err := checkDimensions(h.width, h.height); err != nil {
+		return h, fmt.Errorf("netpbm: %w", err)
+	}
+
+	// Bitmaps have no maxval.
+	h.maxval = 1
+	if h.magic != '1' && h.magic != '4' {
+		if h.maxval, err = readNumber(_r); err != nil {
+			return h, err
+		}
+		if h.maxval < 1 || 65535 < h.maxval {
+			return h, fmt.Errorf("netpbm: maxval %d out of range", h.maxval)
+		}
+	}
+
+	return h, nil
+}
+
+func (this netpbmHeader) model() color.Model {
+	switch {
+	case this.magic == '3' || this.magic == '6':
+		if this.maxval > 255 {
+			return color.RGBA64Model
+		}
+		return color.RGBAModel
+
+	case this.maxval > 255:
+		return color.Gray16Model
+	}
+
+	return color.GrayModel
+}
+
+func decodeNetpbmConfig(_r goio.Reader) (image.Config, error) {
+	h, err := readNetpbmHeader(bufio.NewReader(_r))
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: h.model(), Width: h.width, Height: h.height}, nil
+}
+
+// image returns the image of the size and model of the header along with the
+// function setting its i-th sample, from 0 to maxval, channels being
+// interleaved.
+func (this netpbmHeader) image() (image.Image, func(i, v int)) {
+	rect := image.Rect(0, 0, this.width, this.height)
+
+	switch this.model() {
+	case color.GrayModel:
+		img := image.NewGray(rect)
+		return img, func(i, v int) {
+			img.Pix[i] = uint8(scale(v, this.maxval, 0xff))
+		}
+
+	case color.Gray16Model:
+		img := image.NewGray16(rect)
+		return img, func(i, v int) {
+			v = scale(v, this.maxval, 0xffff)
+			img.Pix[i*2], img.Pix[i*2+1] = uint8(v>>8), uint8(v)
+		}
+
+	case color.RGBAModel:
+		img := image.NewRGBA(rect)
+		return img, func(i, v int) {
+			p := i / 3 * 4
+			img.Pix[p+i%3] = uint8(scale(v, this.maxval, 0xff))
+			img.Pix[p+3] = 0xff
+		}
+	}
+
+	img := image.NewRGBA64(rect)
+	return img, func(i, v int) {
+		p := i / 3 * 8
+		v = scale(v, this.maxval, 0xffff)
+		img.Pix[p+i%3*2], img.Pix[p+i%3*2+1] = uint8(v>>8), uint8(v)
+		img.Pix[p+6], img.Pix[p+7] = 0xff, 0xff
+	}
+}
+
+func decodeNetpbm(_r goio.Reader) (image.Image, error) {
+	r := bufio.NewReader(_r)
+
+	h, err := readNetpbmHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := 1
+	if h.magic == '3' || h.magic == '6' {
+		channels = 3
+	}
+
+	img, set := h.image()
+	samples := h.width * h.height * channels
+
+	switch h.magic {
+	case '1', '2', '3':
+		// Plain formats, one decimal token per sample. PBM samples may
+		// be packed without whitespace, 1 being black.
+		for i := 0; i < samples; {
+			token, err := readToken(r)
+			if err != nil {
+				return nil, unexpected(err)
+			}
+
+			if h.magic == '1' {
+				for _, c := range []byte(token) {
+					if (c != '0' && c != '1') || i == samples {
+						return nil, errNetpbm
+					}
+					set(i, int('1'-c))
+					i++
+				}
+				continue
+			}
+
+			v, err := strconv.Atoi(token)
+			if err != nil || v < 0 || h.maxval < v {
+				return nil, errNetpbm
+			}
+			set(i, v)
+			i++
+		}
+
+	case '4':
+		// One bit per pixel, rows padded to a whole byte, 1 being black.
+		row := make([]byte, (h.width+7)/8)
+		for y := range h.height {
+			if _, err := goio.ReadFull(r, row); err != nil {
+				return nil, unexpected(err)
+			}
+			for x := range h.width {
+				set(y*h.width+x, 1-int(row[x/8]>>(7-x%8))&1)
+			}
+		}
+
+	case '5', '6':
+		// One byte per sample, or two big endian ones above 255, read a
+		// row at a time.
+		size := 1
+		if h.maxval > 255 {
+			size = 2
+		}
+
+		row := make([]byte, h.width*channels*size)
+		for y := range h.height {
+			if _, err := goio.ReadFull(r, row); err != nil {
+				return nil, unexpected(err)
+			}
+			for x := range h.width * channels {
+				v := int(row[x])
+				if size == 2 {
+					v = int(row[x*2])<<8 | int(row[x*2+1])
+				}
+				if v > h.maxval {
+					return nil, errNetpbm
+				}
+				set(y*h.width*channels+x, v)
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// scale rescales _v from [0, _from] to [0, _to], rounding to nearest.
+func scale(_v, _from, _to int) int {
+	if _from == _to {
+		return _v
+	}
+
+	return (_v*_to + _from/2) / _from
+}
+
+func unexpected(_err error) error {
+	if _err == goio.EOF {
+		return goio.ErrUnexpectedEOF
+	}
+
+	return _err
+}
+
+// EncodePGM writes _img as a raw 8 bit PGM (P5) image, truncating samples
+// the same way Write does.
+func EncodePGM(_w goio.Writer, _img *filters.GrayScalePlane) error {
+	w := bufio.NewWriter(_w)
+	fmt.Fprintf(w, "P5\n%d %d\n255\n", _img.Width, _img.Height)
+
+	for y := range _img.Height {
+		for _, v := range _img.Shades[y*_img.Stride : y*_img.Stride+_img.Width] {
+			w.WriteByte(sample(v))
+		}
+	}
+
+	return w.Flush()
+}
+
+// EncodePPM writes _img as a raw 8 bit PPM (P6) image. PPM has no alpha
+// channel, which is dropped.
+func EncodePPM(_w goio.Writer, _img *filters.RGBAPlane) error {
+	w := bufio.NewWriter(_w)
+	fmt.Fprintf(w, "P6\n%d %d\n255\n", _img.Width, _img.Height)
+
+	for y := range _img.Height {
+		row := _img.RGBA[y*_img.Stride : y*_img.Stride+_img.Width*4]
+		for x := 0; x < len(row); x += 4 {
+			w.Write([]byte{sample(row[x]), sample(row[x+1]), sample(row[x+2])})
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,92 @@
+package io_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+)
+
+func TestNetpbmDecode(t *testing.T) {
+	tests := []struct {
+		name, format string
+		data         string
+		want         []float64 // RGBA samples
+	}{
+		{"P1", "pbm", "P1\n# comment\n3 1\n1 0 1\n", []float64{0, 0, 0, 255, 255, 255, 255, 255, 0, 0, 0, 255}},
+		{"P1 packed", "pbm", "P1 2 1 01", []float64{255, 255, 255, 255, 0, 0, 0, 255}},
+		{"P4", "pbm", "P4\n9 1\n\x80\x80", []float64{0, 0, 0, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 0, 0, 0, 255}},
+		{"P2", "pgm", "P2 2 1 # comment\n 4\n0 4", []float64{0, 0, 0, 255, 255, 255, 255, 255}},
+		{"P5", "pgm", "P5 2 1 255\n\x10\xf0", []float64{16, 16, 16, 255, 240, 240, 240, 255}},
+		{"P5 16 bits", "pgm", "P5 1 1 65535\n\x80\x00", []float64{128, 128, 128, 255}},
+		{"P3", "ppm", "P3 1 1 15 15 0 5", []float64{255, 0, 85, 255}},
+		{"P6", "ppm", "P6 1 1 255\n\x01\x02\x03", []float64{1, 2, 3, 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := image.Decode(bytes.NewReader([]byte(tt.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format {
+				t.Errorf("format %q, want %q", format, tt.format)
+			}
+
+			got := io.FromImage(img).RGBA
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d samples, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	for _, data := range []string{"P5 1 1 255\n", "P2 1 1 3 4", "P7 1 1 255\n\x00", "P5 1 1 0\n\x00", "P5 0 1 255\n", "P5 3037000500 3037000500 255\n", "P6 100000 100000 255\n"} {
+		if _, _, err := image.Decode(bytes.NewReader([]byte(data))); err == nil {
+			t.Errorf("decoding %q returned no error", data)
+		}
+	}
+}
+
+func TestNetpbmRoundTrip(t *testing.T) {
+	gray := filters.NewGrayScalePlane(3, 2)
+	rgba := filters.NewRGBAPlane(3, 2)
+	for i := range gray.Shades {
+		gray.Shades[i] = float64(i * 40)
+		rgba.RGBA[i*4], rgba.RGBA[i*4+1], rgba.RGBA[i*4+2], rgba.RGBA[i*4+3] = float64(i), float64(i*2), float64(i*3), 255
+	}
+
+	var buf bytes.Buffer
+	if err := io.EncodePGM(&buf, gray); err != nil {
+		t.Fatal(err)
+	}
+	img, _, err := image.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range gray.Shades {
+		if got := io.FromImage(img).RGBA[i*4]; got != v {
+			t.Fatalf("PGM pixel %d is %v, want %v", i, got, v)
+		}
+	}
+
+	buf.Reset()
+	if err := io.EncodePPM(&buf, rgba); err != nil {
+		t.Fatal(err)
+	}
+	img, _, err = image.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range io.FromImage(img).RGBA {
+		if v != rgba.RGBA[i] {
+			t.Fatalf("PPM sample %d is %v, want %v", i, v, rgba.RGBA[i])
+		}
+	}
+}
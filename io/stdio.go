@@ -0,0 +1,69 @@
+package io
+
+import (
+	"bufio"
+	goio "io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Stdio is the path standing for the standard input when reading and for the
+// standard output when writing.
+const Stdio = "-"
+
+// stdin buffers the standard input, so that its start can be looked at to
+// tell what it holds before it is read.
+var stdin = sync.OnceValue(func() *bufio.Reader { return bufio.NewReader(os.Stdin) })
+
+// open opens _path for reading, or returns the standard input for Stdio.
+func open(_path string) (goio.ReadCloser, error) {
+	if _path == Stdio {
+		return goio.NopCloser(stdin()), nil
+	}
+
+	return os.Open(_path)
+}
+
+// writeFile writes _path with _encode, or the standard output for Stdio.
+//
+// Files are written to a temporary file in the same directory, renamed over
+// _path once _encode succeeded, so that _path is never left truncated. An
+// existing file keeps its permissions.
+func writeFile(_path string, _encode func(goio.Writer) error) (err error) {
+	if _path == Stdio {
+		return _encode(os.Stdout)
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(_path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(_path), "."+filepath.Base(_path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = _encode(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), _path)
+}
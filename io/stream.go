@@ -0,0 +1,241 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	goio "io"
+	"strconv"
+	"strings"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// Raw describes the frames of a headerless stream of packed 8 bit RGB
+// pixels, such as the output of ffmpeg -f rawvideo -pix_fmt rgb24.
+type Raw struct {
+	Width, Height int
+	// Rate is the number of frames per second.
+	Rate float64
+}
+
+// Stream reads the frames of a raw video stream one at a time, either
+// YUV4MPEG2 or packed RGB24.
+//
+// https://wiki.multimedia.cx/index.php/YUV4MPEG2
+type Stream struct {
+	Width, Height int
+	// Rate is the number of frames per second, 0 when the stream does not
+	// give it.
+	Rate float64
+
+	r *bufio.Reader
+	c goio.Closer
+
+	// y4m tells frames are preceded by a FRAME line.
+	y4m bool
+	// chroma is the size of the Cb and Cr planes of Y4M frames, 0 for
+	// grayscale ones.
+	chromaWidth, chromaHeight int
+	full                      bool
+
+	buf []byte
+}
+
+const y4mMagic = "YUV4MPEG2 "
+
+var errY4M = errors.New("y4m: invalid stream")
+
+// OpenStream opens the stream at _path, Stdio reading the standard input.
+// Streams starting with the YUV4MPEG2 signature describe themselves, others
+// are read as RGB24 frames of the size given by _raw.
+func OpenStream(_path string, _raw Raw) (*Stream, error) {
+	file, err := open(_path)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := NewStream(file, _raw)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	stream.c = file
+	return stream, nil
+}
+
+// NewStream reads a stream from _r, as OpenStream does.
+func NewStream(_r goio.Reader, _raw Raw) (*Stream, error) {
+	r := bufio.NewReaderSize(_r, 1<<16)
+
+	if magic, _ := r.Peek(len(y4mMagic)); string(magic) == y4mMagic {
+		return newY4M(r)
+	}
+
+	if _raw.Width == 0 && _raw.Height == 0 {
+		return nil, errors.New("stream: not a YUV4MPEG2 stream, the size of its RGB24 frames is needed")
+	}
+	if err := checkDimensions(_raw.Width, _raw.Height); err != nil {
+		return nil, fmt.Errorf("stream: %w", err)
+	}
+
+	return &Stream{
+		Width: _raw.Width, Height: _raw.Height, Rate: _raw.Rate,
+		r:   r,
+		buf: make([]byte, _raw.Width*_raw.Height*3),
+	}, nil
+}
+
+// newY4M reads the header of a YUV4MPEG2 stream.
+func newY4M(_r *bufio.Reader) (*Stream, error) {
+	line, err := _r.ReadString('\n')
+	if err != nil {
+		return nil, errY4M
+	}
+
+	this := &Stream{r: _r, y4m: true}
+	colorspace := "420jpeg"
+
+	for _, param := range strings.Fields(strings.TrimPrefix(line, y4mMagic)) {
+		value := param[1:]
+
+		switch param[0] {
+		case 'W':
+			this.Width, err = strconv.Atoi(value)
+		case 'H':
+			this.Height, err = strconv.Atoi(value)
+		case 'C':
+			colorspace = value
+		case 'F':
+			num, den, ok := strings.Cut(value, ":")
+			n, errN := strconv.ParseFloat(num, 64)
+			d, errD := strconv.ParseFloat(den, 64)
+			if !ok || errN != nil || errD != nil || d == 0 {
+				return nil, fmt.Errorf("y4m: invalid frame rate %q", value)
+			}
+			this.Rate = n / d
+		case 'X':
+			// Written by ffmpeg for full range streams.
+			this.full = value == "COLORRANGE=FULL"
+		}
+
+		if err != nil {
+			return nil, errY4M
+		}
+	}
+
+	if err := checkDimensions(this.Width, this.Height); err != nil {
+		return nil, fmt.Errorf("y4m: %w", err)
+	}
+
+	switch colorspace {
+	case "420jpeg", "420paldv", "420mpeg2", "420":
+		this.chromaWidth, this.chromaHeight = (this.Width+1)/2, (this.Height+1)/2
+	case "422":
+		this.chromaWidth, this.chromaHeight = (this.Width+1)/2, this.Height
+	case "444":
+		this.chromaWidth, this.chromaHeight = this.Width, this.Height
+	case "mono":
+	default:
+		return nil, fmt.Errorf("y4m: unsupported colorspace %q", colorspace)
+	}
+
+	this.buf = make([]byte, this.Width*this.Height+2*this.chromaWidth*this.chromaHeight)
+	return this, nil
+}
+
+// read reads the next frame into this.buf.
+func (this *Stream) read() error {
+	if this.y4m {
+		line, err := this.r.ReadSlice('\n')
+		if err == goio.EOF && len(line) == 0 {
+			return goio.EOF
+		}
+		if err != nil || !bytes.HasPrefix(line, []byte("FRAME")) {
+			return errY4M
+		}
+	}
+
+	n, err := goio.ReadFull(this.r, this.buf)
+	if err == goio.EOF && !this.y4m {
+		return goio.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("stream: truncated frame of %d bytes out of %d", n, len(this.buf))
+	}
+
+	return nil
+}
+
+// Next decodes the next frame, returning io.EOF at the end of the stream.
+func (this *Stream) Next() (*filters.RGBAPlane, error) {
+	if err := this.read(); err != nil {
+		return nil, err
+	}
+
+	out := filters.NewRGBAPlane(this.Width, this.Height)
+	if !this.y4m {
+		for i, v := range this.buf {
+			out.RGBA[i/3*4+i%3] = float64(v)
+		}
+		for i := 3; i < len(out.RGBA); i += 4 {
+			out.RGBA[i] = 255
+		}
+
+		return out, nil
+	}
+
+	luma := this.buf[:this.Width*this.Height]
+	cb := this.buf[len(luma) : len(luma)+this.chromaWidth*this.chromaHeight]
+	cr := this.buf[len(luma)+len(cb):]
+
+	for y := range this.Height {
+		row := out.RGBA[y*out.Stride:]
+		cy := y * this.chromaHeight / this.Height
+
+		for x := range this.Width {
+			l, u, v := float64(luma[y*this.Width+x]), 128., 128.
+			if this.chromaWidth > 0 {
+				i := cy*this.chromaWidth + x*this.chromaWidth/this.Width
+				u, v = float64(cb[i]), float64(cr[i])
+			}
+
+			r, g, b := yCbCrToRGB(l, u, v, this.full)
+			row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = r, g, b, 255
+		}
+	}
+
+	return out, nil
+}
+
+// Skip discards the next frame without decoding it, returning io.EOF at the
+// end of the stream.
+func (this *Stream) Skip() error {
+	return this.read()
+}
+
+// Close closes the file the stream was opened from.
+func (this *Stream) Close() error {
+	if this.c == nil {
+		return nil
+	}
+
+	return this.c.Close()
+}
+
+// yCbCrToRGB converts a BT.601 sample, in the limited range of video unless
+// _full, to RGB clamped to [0, 255].
+func yCbCrToRGB(_y, _cb, _cr float64, _full bool) (float64, float64, float64) {
+	y, cb, cr := _y, _cb-128, _cr-128
+	if !_full {
+		y, cb, cr = (y-16)*255/219, cb*255/224, cr*255/224
+	}
+
+	r := y + 1.402*cr
+	g := y - 0.344136*cb - 0.714136*cr
+	b := y + 1.772*cb
+
+	return min(max(r, 0), 255), min(max(g, 0), 255), min(max(b, 0), 255)
+}
@@ -0,0 +1,102 @@
+package io_test
+
+import (
+	"errors"
+	goio "io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/io"
+)
+
+func TestStreamY4M(t *testing.T) {
+	// 2x2 4:2:0 frames: white, black, then a full range red one.
+	data := "YUV4MPEG2 W2 H2 F30000:1001 Ip C420jpeg\n" +
+		"FRAME\n\xeb\xeb\xeb\xeb\x80\x80" +
+		"FRAME Ixyz\n\x10\x10\x10\x10\x80\x80"
+	stream, err := io.NewStream(strings.NewReader(data), io.Raw{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.Width != 2 || stream.Height != 2 || math.Abs(stream.Rate-29.97) > 0.01 {
+		t.Errorf("stream is %dx%d at %g fps", stream.Width, stream.Height, stream.Rate)
+	}
+
+	for _, want := range []float64{255, 0} {
+		img, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(img.RGBA[:4], []float64{want, want, want, 255}) {
+			t.Errorf("pixel %v, want %v", img.RGBA[:4], want)
+		}
+	}
+	if _, err := stream.Next(); err != goio.EOF {
+		t.Errorf("end of stream gives %v", err)
+	}
+
+	full := "YUV4MPEG2 W1 H1 F25:1 C444 XCOLORRANGE=FULL\nFRAME\n\x4c\x55\xff"
+	stream, err = io.NewStream(strings.NewReader(full), io.Raw{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := stream.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b := img.RGBA[0], img.RGBA[1], img.RGBA[2]; r < 250 || g > 5 || b > 5 {
+		t.Errorf("red decodes to %g %g %g", r, g, b)
+	}
+}
+
+func TestStreamRGB24(t *testing.T) {
+	data := "\x01\x02\x03\x04\x05\x06" + "\x07\x08\x09\x0a\x0b\x0c" + "\xff"
+
+	if _, err := io.NewStream(strings.NewReader(data), io.Raw{}); err == nil {
+		t.Error("stream without size was accepted")
+	}
+	if _, err := io.NewStream(strings.NewReader(data), io.Raw{Width: 3037000500, Height: 3037000500}); err == nil {
+		t.Error("stream of 9e18 pixels was accepted")
+	}
+
+	stream, err := io.NewStream(strings.NewReader(data), io.Raw{Width: 2, Height: 1, Rate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Skip(); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := stream.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{7, 8, 9, 255, 10, 11, 12, 255}; !slices.Equal(img.RGBA, want) {
+		t.Errorf("frame %v, want %v", img.RGBA, want)
+	}
+
+	if _, err := stream.Next(); err == nil || errors.Is(err, goio.EOF) {
+		t.Errorf("truncated frame gives %v", err)
+	}
+}
+
+func TestStreamInvalid(t *testing.T) {
+	for _, data := range []string{
+		"YUV4MPEG2 W2\n",
+		"YUV4MPEG2 W2 H2 C420p10\n",
+		"YUV4MPEG2 W2 H2 Fx\n",
+		"YUV4MPEG2 W1 H1 Cmono\nFRAM\n\x00",
+		"YUV4MPEG2 W3037000500 H3037000500\n",
+		"YUV4MPEG2 W-2 H2\n",
+	} {
+		stream, err := io.NewStream(strings.NewReader(data), io.Raw{})
+		if err == nil {
+			_, err = stream.Next()
+		}
+		if err == nil {
+			t.Errorf("%q was accepted", data)
+		}
+	}
+}
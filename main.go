@@ -1,81 +1,126 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	_ "image/jpeg"
+	"os"
+	"strings"
 
+	"github.com/IJJA3141/GoSCII/config"
 	"github.com/IJJA3141/GoSCII/io"
-	"github.com/IJJA3141/GoSCII/tui"
 )
 
-var in string
-var out string
-
-func init() {
-	io.CreateStringFlag(&in, "in", "./example_images/test_uwu.png", "path to the input image")
-	io.CreateStringFlag(&out, "out", "out.png", "path to the output image")
+// command is a subcommand of goscii, parsing its own flags out of args.
+type command struct {
+	name, summary string
+	run           func(args []string) error
 }
 
-func main() {
-	flag.Parse()
+var commands = []command{
+	{"render", "render an image and print it to stdout", runRender},
+	{"view", "render an image and open it in the viewer", runView},
+	{"play", "play a raw video stream in the viewer", runPlay},
+	{"convert", "render an image and write it to a file", runConvert},
+	{"batch", "render many images and write them to files", runBatch},
+	{"bench", "time the pipeline over images", runBench},
+	{"palette", "inspect the density of palettes and calibrate them", runPalette},
+}
 
-	img, err := io.Read(in)
-	if err != nil {
-		fmt.Println(err)
-		return
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: goscii <command> [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun goscii <command> -h for the flags of a command. Without a command,\ngoscii views the image, as with goscii view.\n\n")
+	fmt.Fprintf(os.Stderr, "Flags default to the values of the config file, %s, those of\nthe profile chosen with -profile taking precedence, then to the environment\nvariables GOSCII_<NAME>.\n", config.Path())
+}
 
-	// outWidth := 80
-	// // outHeight := int(80/3)
-	// outHeight := float64(img.Height) / float64(img.Width) * float64(outWidth)
+// newFlags returns the flags of the command called name, along with -profile.
+func newFlags(name, args, desc string) *io.Flags {
+	flags := io.NewFlags("goscii "+name, "usage: "+strings.TrimSpace("goscii "+name+" [flags] "+args)+"\n\n"+desc)
 
-	// img, err = img.LanczosResize(img.Width, img.Height/2, 3)
-	// if err != nil {
-	// 	fmt.Println(err)
-	// 	return
-	// }
+	var profile string
+	flags.String(&profile, "profile", "", "", "profile of the config file the flags default to")
 
-	gray := img.ToGrayScale()
+	return flags
+}
 
-	gray, err = gray.BayerDithering(8)
-	if err != nil {
-		fmt.Println(err)
-		return
+// cfg is the config file, loaded by main.
+var cfg *config.Config
+
+// profileOf returns the profile args select. It has to be known before the
+// flags are parsed, since it picks their defaults.
+func profileOf(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		name, value, ok := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "profile" {
+			continue
+		}
+		if ok {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
 	}
 
-	img, err = img.LanczosResize(img.Width/2, img.Height/4, 3)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
+	return os.Getenv("GOSCII_PROFILE")
+}
 
-	ascii := gray.Braille(200)
-	// ascii := gray.Ascii([]rune(
-	// 	" .:,`';^-_!~\"</>*+?\\v)x=cJY|Lil{}7T(1CetzVXnorsaujyUfI]23AFHZ5S[K#%4hw6&KOp9PbGmdq$08DERNQgMWB@",
-	// ))
-	// edge := gray.SobelEdgeDetection()
-	// ascii := edge.Ascii(750, []rune(
-	// 	// "→↗↑↖←↙↓↘",
-	// 	// "↖↖",
-	// 	// "123455678",
-	// 	// "←↖↑↗→↘↓↙←",
-	// 	"|/-\\|/-\\|",
-	// ))
-
-	color, err := ascii.Colorize(img)
+// parse sets the flags from the config file, the GOSCII_* environment
+// variables and args.
+func parse(flags *io.Flags, args []string) error {
+	defaults, err := cfg.Defaults(profileOf(args))
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
-	// fmt.Println(color.Buffer())
+	return flags.Parse(args, defaults, "GOSCII")
+}
 
-	tui.Start(color)
+func main() {
+	args := os.Args[1:]
+	name := "view"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	err = io.Write(out, gray.ToRGBA())
+	if name == "help" {
+		usage()
+		return
+	}
+
+	var err error
+	cfg, err = config.Load(config.Path())
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		err := cmd.run(args)
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return
+		case errors.Is(err, io.ErrUsage):
+			os.Exit(2)
+		case err != nil:
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		return
 	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
 }
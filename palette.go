@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/IJJA3141/GoSCII/io"
+	"github.com/IJJA3141/GoSCII/pipeline"
+)
+
+// printable is every printable ASCII character, the default charset palettes
+// are calibrated from.
+var printable = func() string {
+	var b strings.Builder
+	for c := ' '; c <= '~'; c++ {
+		b.WriteRune(c)
+	}
+	return b.String()
+}()
+
+func runPalette(args []string) error {
+	var palette, charset string
+	var levels int
+
+	set := newFlags("palette", "", "Prints how much of a cell every character of -palette inks, as measured on the\nfont renders are exported with, and the palette ordered from the lightest\ncharacter to the darkest. With -levels, builds a palette of that many\ncharacters out of -charset instead, as evenly spaced in density as possible.")
+	set.String(&palette, "palette", "p", pipeline.Params("ascii")[0].Default, "characters to inspect")
+	set.Int(&levels, "levels", "l", 0, "number of characters of the calibrated palette, 0 to inspect -palette")
+	set.String(&charset, "charset", "c", printable, "characters the calibrated palette is made of")
+	if err := parse(set, args); err != nil {
+		return err
+	}
+
+	if levels > 0 {
+		return calibrate(charset, levels)
+	}
+
+	chars := []rune(palette)
+	coverage := make([]float64, len(chars))
+	for i, char := range chars {
+		coverage[i] = io.Coverage(char)
+		fmt.Printf("%q\t%5.1f%%\t%s\n", char, coverage[i]*100, strings.Repeat("#", int(coverage[i]*50+.5)))
+	}
+
+	sorted := slices.Clone(chars)
+	slices.SortStableFunc(sorted, func(a, b rune) int {
+		return compare(io.Coverage(a), io.Coverage(b))
+	})
+
+	if !slices.IsSorted(coverage) {
+		fmt.Println("\nthe palette is not ordered by density")
+	}
+	fmt.Printf("\nsorted: %s\n", strconv.Quote(string(sorted)))
+
+	return nil
+}
+
+// calibrate prints the palette of levels characters of charset closest to
+// evenly spaced densities.
+func calibrate(charset string, levels int) error {
+	chars := []rune(charset)
+	if len(chars) < levels {
+		return fmt.Errorf("charset has %d characters, fewer than %d levels", len(chars), levels)
+	}
+
+	coverage := make(map[rune]float64, len(chars))
+	for _, char := range chars {
+		coverage[char] = io.Coverage(char)
+	}
+	slices.SortStableFunc(chars, func(a, b rune) int { return compare(coverage[a], coverage[b]) })
+
+	lightest, darkest := coverage[chars[0]], coverage[chars[len(chars)-1]]
+
+	// Every level takes the closest character left, keeping the palette
+	// ordered by picking from past the previous one.
+	out := make([]rune, 0, levels)
+	next := 0
+	for level := range levels {
+		target := lightest
+		if levels > 1 {
+			target += (darkest - lightest) * float64(level) / float64(levels-1)
+		}
+
+		// Leave enough characters for the levels to come.
+		last := len(chars) - (levels - level)
+		best := next
+		for i := next; i <= last; i++ {
+			if math.Abs(coverage[chars[i]]-target) < math.Abs(coverage[chars[best]]-target) {
+				best = i
+			}
+		}
+
+		out = append(out, chars[best])
+		next = best + 1
+	}
+
+	for _, char := range out {
+		fmt.Printf("%q\t%5.1f%%\n", char, coverage[char]*100)
+	}
+	fmt.Printf("\npalette: %s\n", strconv.Quote(string(out)))
+
+	return nil
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
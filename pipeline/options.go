@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Options describes a pipeline by the render it makes, as the flags and
+// config profiles do.
+type Options struct {
+	// Renderer is braille, ascii or edges.
+	Renderer string
+	// Width is the width of the render in characters. Zero keeps the size of
+	// the image for Braille, one cell per 2x4 pixels, and makes 100 columns
+	// otherwise.
+	Width int
+	// Aspect is the height of the cells of the terminal over their width.
+	Aspect float64
+	// Palette is the palette of the ascii renderer.
+	Palette string
+	// Color colors the characters with the image.
+	Color bool
+}
+
+// DefaultOptions is the options of Default.
+var DefaultOptions = Options{Renderer: "braille", Aspect: 2, Palette: " .:-=+*#%@", Color: true}
+
+// Pipeline returns the pipeline making the render described by this.
+func (this Options) Pipeline() (Pipeline, error) {
+	if this.Aspect <= 0 {
+		return nil, fmt.Errorf("aspect must be positive")
+	}
+
+	var out Pipeline
+	number := func(v float64) string { return strconv.FormatFloat(v, 'g', 4, 64) }
+
+	switch this.Renderer {
+	case "braille":
+		// Braille cells hold 2x4 dots, square for an aspect of 2, a taller
+		// cell spreading its 4 rows of dots over more of the image. A width
+		// of 0 keeps that of the image.
+		if ratio := 2 / this.Aspect; this.Width > 0 || ratio != 1 {
+			out = append(out, Stage{"resize", []string{strconv.Itoa(max(0, this.Width) * 2), number(ratio)}})
+		}
+		out = append(out, Stage{Name: "gray"}, Stage{"dither", []string{"8"}}, Stage{"braille", []string{"200"}})
+
+	case "ascii", "edges":
+		width := this.Width
+		if width <= 0 {
+			width = 100
+		}
+		out = append(out, Stage{"resize", []string{strconv.Itoa(width), number(1 / this.Aspect)}}, Stage{Name: "gray"})
+
+		if this.Renderer == "ascii" {
+			out = append(out, Stage{"ascii", []string{this.Palette}})
+		} else {
+			out = append(out, Stage{"edges", []string{"200"}})
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown renderer %q", this.Renderer)
+	}
+
+	if this.Color {
+		out = append(out, Stage{Name: "colorize"})
+	}
+
+	for _, stage := range out {
+		if err := stage.Check(); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
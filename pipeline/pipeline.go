@@ -0,0 +1,497 @@
+// Package pipeline describes renders as a sequence of filters, parsed from
+// text such as
+//
+//	gray | dither 8 | braille 200 | colorize
+//
+// Stages are separated by '|' or new lines and their arguments by spaces.
+// Arguments holding spaces or special characters are double quoted, and '#'
+// starts a comment running to the end of the line, which lets pipelines be
+// kept in files.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// Default is the pipeline used when none is given: colored Braille.
+const Default = "gray | dither 8 | braille 200 | colorize"
+
+// Stage is one filter of a pipeline along with its arguments.
+type Stage struct {
+	Name string
+	Args []string
+}
+
+// Pipeline is a sequence of stages, run in order on a source image.
+type Pipeline []Stage
+
+// Param describes an argument of a stage.
+type Param struct {
+	Name    string
+	Default string
+	// Number tells numeric arguments apart from text ones, and Integer those
+	// that have to be whole numbers, such as sizes.
+	Number, Integer bool
+	// Min and Max bound numeric arguments, when they are not both zero.
+	Min, Max float64
+}
+
+// Bounded reports whether the argument has to lie between Min and Max.
+func (this Param) Bounded() bool {
+	return this.Min != 0 || this.Max != 0
+}
+
+// spec describes a stage: its parameters and how it runs.
+type spec struct {
+	params []Param
+	run    func(ctx context.Context, state *state, args []string) error
+}
+
+var specs map[string]spec
+
+func init() {
+	specs = map[string]spec{
+		"resize": {
+			params: []Param{{Name: "width", Number: true, Integer: true, Min: 0, Max: 16384}, {Name: "ratio", Default: "1", Number: true, Min: 0.01, Max: 100}},
+			run:    resize,
+		},
+		"gray":   {run: gray},
+		"invert": {run: invert},
+		"dither": {
+			// The Bayer matrix is 2^order pixels wide.
+			params: []Param{{Name: "order", Default: "8", Number: true, Integer: true, Min: 1, Max: 8}},
+			run:    dither,
+		},
+		"braille": {
+			params: []Param{{Name: "threshold", Default: "128", Number: true, Min: 0, Max: 255}},
+			run:    braille,
+		},
+		"ascii": {
+			params: []Param{{Name: "palette", Default: " .:-=+*#%@"}},
+			run:    ascii,
+		},
+		"edges": {
+			params: []Param{{Name: "threshold", Default: "200", Number: true}, {Name: "palette", Default: `|/-\|/-\|`}},
+			run:    edges,
+		},
+		"colorize": {run: colorize},
+	}
+}
+
+// Names returns the names of the known stages.
+func Names() []string {
+	return []string{"resize", "gray", "invert", "dither", "braille", "ascii", "edges", "colorize"}
+}
+
+// Params returns the parameters of the stage called name, nil for unknown
+// stages.
+func Params(name string) []Param {
+	return specs[name].params
+}
+
+// Arg returns the i-th argument of the stage, or its default value when it
+// is not given.
+func (this Stage) Arg(i int) string {
+	if i < len(this.Args) {
+		return this.Args[i]
+	}
+
+	return specs[this.Name].params[i].Default
+}
+
+// Check reports unknown stages, missing or extra arguments, and arguments
+// that should be numbers but are not, are not whole or lie out of their
+// bounds.
+func (this Stage) Check() error {
+	spec, ok := specs[this.Name]
+	if !ok {
+		return fmt.Errorf("unknown stage %q", this.Name)
+	}
+
+	if len(this.Args) > len(spec.params) {
+		return fmt.Errorf("%s: %d arguments, want at most %d", this.Name, len(this.Args), len(spec.params))
+	}
+
+	for i, param := range spec.params {
+		arg := this.Arg(i)
+		if arg == "" && param.Number {
+			return fmt.Errorf("%s: missing %s", this.Name, param.Name)
+		}
+		if !param.Number {
+			continue
+		}
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %s is not a number: %q", this.Name, param.Name, arg)
+		}
+		if param.Integer && v != math.Trunc(v) {
+			return fmt.Errorf("%s: %s must be a whole number, not %s", this.Name, param.Name, arg)
+		}
+		if param.Bounded() && (v < param.Min || v > param.Max) {
+			return fmt.Errorf("%s: %s must be between %g and %g, not %s", this.Name, param.Name, param.Min, param.Max, arg)
+		}
+	}
+
+	return nil
+}
+
+// number returns the i-th argument of the stage, checked to be a number.
+func (this Stage) number(i int) float64 {
+	v, _ := strconv.ParseFloat(this.Arg(i), 64)
+	return v
+}
+
+// String formats the stage the way Parse reads it.
+func (this Stage) String() string {
+	out := this.Name
+	for _, arg := range this.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n|#\"\\") {
+			arg = strconv.Quote(arg)
+		}
+		out += " " + arg
+	}
+
+	return out
+}
+
+// String formats the pipeline the way Parse reads it.
+func (this Pipeline) String() string {
+	stages := make([]string, len(this))
+	for i, stage := range this {
+		stages[i] = stage.String()
+	}
+
+	return strings.Join(stages, " | ")
+}
+
+// Parse reads a pipeline and checks its stages.
+func Parse(text string) (Pipeline, error) {
+	var out Pipeline
+	var stage []string
+
+	end := func() error {
+		if len(stage) == 0 {
+			return nil
+		}
+
+		next := Stage{Name: stage[0], Args: stage[1:]}
+		if err := next.Check(); err != nil {
+			return err
+		}
+
+		out = append(out, next)
+		stage = nil
+		return nil
+	}
+
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == '|' || c == '\n':
+			if err := end(); err != nil {
+				return nil, err
+			}
+			i++
+
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+
+		case c == '#':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+
+		case c == '"':
+			quoted, err := strconv.QuotedPrefix(text[i:])
+			if err != nil {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			arg, _ := strconv.Unquote(quoted)
+			stage = append(stage, arg)
+			i += len(quoted)
+
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\r\n|#\"", rune(text[i])) {
+				i++
+			}
+			stage = append(stage, text[start:i])
+		}
+	}
+
+	if err := end(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty pipeline")
+	}
+
+	return out, nil
+}
+
+// Scaled returns the pipeline rendering factor times as large as this one,
+// for a source image width pixels wide: the widths of its resize stages are
+// scaled, and a resize of the source is prepended when there is none.
+func (this Pipeline) Scaled(factor float64, width int) Pipeline {
+	out := make(Pipeline, 0, len(this)+1)
+	resized := false
+
+	// current is the width of the image before the stage, which resizes to
+	// a width of 0 keep.
+	current := float64(width)
+	for _, stage := range this {
+		if stage.Name == "resize" {
+			if w := stage.number(0); w != 0 {
+				current = w
+			}
+			args := slices.Clone(stage.Args)
+			args[0] = strconv.Itoa(max(1, int(math.Round(current*factor))))
+			stage, resized = Stage{stage.Name, args}, true
+		}
+		out = append(out, stage)
+	}
+
+	if !resized && factor != 1 {
+		resize := Stage{"resize", []string{strconv.Itoa(max(1, int(math.Round(float64(width)*factor))))}}
+		out = append(Pipeline{resize}, out...)
+	}
+
+	return out
+}
+
+// MustParse is like Parse but panics on error, for pipelines known to be
+// valid.
+func MustParse(text string) Pipeline {
+	out, err := Parse(text)
+	if err != nil {
+		panic(err)
+	}
+
+	return out
+}
+
+// Result holds what a pipeline produced.
+type Result struct {
+	// Source is the image colors are taken from, after its resizes.
+	Source *filters.RGBAPlane
+	// Gray is the last grayscale image, nil if the pipeline made none.
+	Gray *filters.GrayScalePlane
+	// Art is the render, an *AsciiPlane or an *AsciiColorPlane.
+	Art filters.Ascii
+
+	// Times holds the time spent in every stage.
+	Times []time.Duration
+}
+
+// state is what the stages work on.
+type state struct {
+	rgba  *filters.RGBAPlane
+	gray  *filters.GrayScalePlane
+	ascii *filters.AsciiPlane
+	color *filters.AsciiColorPlane
+
+	// last holds what every stage made of the previous frame when running
+	// through Temporal, and stage is the index of the running stage.
+	last       []output
+	stage      int
+	hysteresis float64
+}
+
+// output is what a stage leaves for the next frame.
+type output struct {
+	gray  *filters.GrayScalePlane
+	ascii *filters.AsciiPlane
+}
+
+// previous returns what the running stage made of the previous frame, zero
+// when there is none.
+func (this *state) previous() output {
+	if this.stage < len(this.last) {
+		return this.last[this.stage]
+	}
+
+	return output{}
+}
+
+// grayscale returns the grayscale image, converting the source when there is
+// none yet.
+func (this *state) grayscale() *filters.GrayScalePlane {
+	if this.gray == nil {
+		this.gray = this.rgba.ToGrayScale()
+	}
+
+	return this.gray
+}
+
+// Run runs the stages on img, which is left untouched. Pipelines that end
+// before producing any text render the last image as ASCII with the default
+// palette.
+func (this Pipeline) Run(ctx context.Context, img *filters.RGBAPlane) (*Result, error) {
+	out, _, err := this.run(ctx, &state{rgba: img})
+	return out, err
+}
+
+// Temporal runs a pipeline over the successive frames of an animation or a
+// video. The dither, braille and ascii stages keep what they made of the
+// previous frame unless the image changed by more than Hysteresis gray
+// levels, so that noise and slow fades do not make static regions flicker.
+type Temporal struct {
+	Pipeline   Pipeline
+	Hysteresis float64
+
+	last []output
+}
+
+// Run runs the pipeline on the next frame.
+func (this *Temporal) Run(ctx context.Context, img *filters.RGBAPlane) (*Result, error) {
+	out, last, err := this.Pipeline.run(ctx, &state{rgba: img, last: this.last, hysteresis: this.Hysteresis})
+	if err != nil {
+		return nil, err
+	}
+
+	this.last = last
+	return out, nil
+}
+
+// Reset forgets the previous frame, for the next one not to follow it.
+func (this *Temporal) Reset() {
+	this.last = nil
+}
+
+// run runs the stages on state, returning what each of them made.
+func (this Pipeline) run(ctx context.Context, state *state) (*Result, []output, error) {
+	times := make([]time.Duration, len(this))
+	outputs := make([]output, len(this))
+
+	for i, stage := range this {
+		if err := stage.Check(); err != nil {
+			return nil, nil, err
+		}
+
+		state.stage = i
+		start := time.Now()
+		if err := specs[stage.Name].run(ctx, state, stage.Args); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", stage.Name, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		times[i] = time.Since(start)
+		outputs[i] = output{state.gray, state.ascii}
+	}
+
+	out := &Result{Source: state.rgba, Gray: state.gray, Times: times}
+	switch {
+	case state.color != nil:
+		out.Art = state.color
+	case state.ascii != nil:
+		out.Art = state.ascii
+	default:
+		out.Art = state.grayscale().Ascii([]rune(specs["ascii"].params[0].Default))
+	}
+
+	return out, outputs, nil
+}
+
+func resize(ctx context.Context, state *state, args []string) error {
+	stage := Stage{"resize", args}
+	width, ratio := int(stage.number(0)), stage.number(1)
+	if width < 0 || ratio <= 0 {
+		return fmt.Errorf("width and ratio must be positive")
+	}
+
+	// The grayscale image is resized once there is one, the source otherwise.
+	// A width of 0 keeps that of the image, only the ratio changing.
+	var err error
+	if state.gray != nil {
+		if width == 0 {
+			width = state.gray.Width
+		}
+		height := max(1, int(float64(state.gray.Height*width)/float64(state.gray.Width)*ratio))
+		state.gray, err = state.gray.LanczosResizeContext(ctx, width, height, 3)
+	} else {
+		if width == 0 {
+			width = state.rgba.Width
+		}
+		height := max(1, int(float64(state.rgba.Height*width)/float64(state.rgba.Width)*ratio))
+		state.rgba, err = state.rgba.LanczosResizeContext(ctx, width, height, 3)
+	}
+
+	return err
+}
+
+func gray(ctx context.Context, state *state, args []string) error {
+	state.gray = state.rgba.ToGrayScale()
+	return nil
+}
+
+func invert(ctx context.Context, state *state, args []string) error {
+	var err error
+	state.gray, err = state.grayscale().InverseContext(ctx)
+	return err
+}
+
+func dither(ctx context.Context, state *state, args []string) error {
+	var err error
+	state.gray, err = state.grayscale().BayerDitheringTemporal(ctx, int(Stage{"dither", args}.number(0)), state.previous().gray, state.hysteresis)
+	return err
+}
+
+func braille(ctx context.Context, state *state, args []string) error {
+	state.ascii, state.color = state.grayscale().BrailleTemporal(Stage{"braille", args}.number(0), state.previous().ascii, state.hysteresis), nil
+	return nil
+}
+
+func ascii(ctx context.Context, state *state, args []string) error {
+	palette := []rune(Stage{"ascii", args}.Arg(0))
+	if len(palette) == 0 {
+		return fmt.Errorf("empty palette")
+	}
+
+	state.ascii, state.color = state.grayscale().AsciiTemporal(palette, state.previous().ascii, state.hysteresis), nil
+	return nil
+}
+
+func edges(ctx context.Context, state *state, args []string) error {
+	stage := Stage{"edges", args}
+	palette := []rune(stage.Arg(1))
+	if len(palette) == 0 {
+		return fmt.Errorf("empty palette")
+	}
+
+	edges, err := state.grayscale().SobelEdgeDetectionContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	state.ascii, state.color = edges.Ascii(stage.number(0), palette), nil
+	return nil
+}
+
+// colorize colors the text with the source image, resized to one pixel per
+// character.
+func colorize(ctx context.Context, state *state, args []string) error {
+	if state.ascii == nil {
+		return fmt.Errorf("no text to colorize")
+	}
+
+	colors := state.rgba
+	if colors.Width != state.ascii.Width || colors.Height != state.ascii.Height {
+		var err error
+		colors, err = colors.LanczosResizeContext(ctx, state.ascii.Width, state.ascii.Height, 3)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	state.color, err = state.ascii.Colorize(colors)
+	return err
+}
@@ -0,0 +1,160 @@
+package pipeline_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/pipeline"
+)
+
+func TestParse(t *testing.T) {
+	got, err := pipeline.Parse("resize 80 .5 | gray\n# a comment | not a stage\nascii \" .:#\" | colorize # trailing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := pipeline.Pipeline{
+		{Name: "resize", Args: []string{"80", ".5"}},
+		{Name: "gray"},
+		{Name: "ascii", Args: []string{" .:#"}},
+		{Name: "colorize"},
+	}
+	if !slices.EqualFunc(got, want, func(a, b pipeline.Stage) bool {
+		return a.Name == b.Name && slices.Equal(a.Args, b.Args)
+	}) {
+		t.Fatalf("Parse returned %v, want %v", got, want)
+	}
+
+	// String is read back as the same pipeline.
+	again, err := pipeline.Parse(got.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != got.String() {
+		t.Errorf("String round trip gives %q, want %q", again.String(), got.String())
+	}
+
+	for _, text := range []string{"", "blur 3", "resize", "dither eight", "dither 0", "dither 20", "dither 64", "dither 2.5", "resize -40", "resize 40 0", "resize 40.5", "braille 300", "braille 1 2", `ascii "unterminated`} {
+		if _, err := pipeline.Parse(text); err == nil {
+			t.Errorf("Parse(%q) succeeded", text)
+		}
+	}
+}
+
+func TestDefaultMatchesFilters(t *testing.T) {
+	img := filters.NewRGBAPlane(37, 29)
+	for i := range img.RGBA {
+		img.RGBA[i] = float64(i * 7 % 256)
+	}
+
+	got, err := pipeline.MustParse(pipeline.Default).Run(context.Background(), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dithered, err := img.ToGrayScale().BayerDithering(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	colors, err := img.LanczosResize(img.Width/2, img.Height/4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := dithered.Braille(200).Colorize(colors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(got.Art.(*filters.AsciiColorPlane).Buffer(), want.Buffer()) {
+		t.Error("default pipeline differs from the filters it stands for")
+	}
+}
+
+func TestTemporal(t *testing.T) {
+	frame := func(v float64) *filters.RGBAPlane {
+		img := filters.NewRGBAPlane(8, 8)
+		for i := range img.RGBA {
+			img.RGBA[i] = v
+		}
+		return img
+	}
+
+	temporal := pipeline.Temporal{Pipeline: pipeline.MustParse("gray | ascii \" .:#\""), Hysteresis: 10}
+	first, err := temporal.Run(context.Background(), frame(88))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := temporal.Run(context.Background(), frame(82))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(first.Art.Get(0, 0, 8, 8), second.Art.Get(0, 0, 8, 8)) {
+		t.Error("a small change of the frame changed its characters")
+	}
+
+	temporal.Reset()
+	third, err := temporal.Run(context.Background(), frame(82))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Equal(first.Art.Get(0, 0, 8, 8), third.Art.Get(0, 0, 8, 8)) {
+		t.Error("Reset kept the previous frame")
+	}
+}
+
+func TestScaled(t *testing.T) {
+	for _, tt := range []struct {
+		pipeline string
+		factor   float64
+		want     string
+	}{
+		{"resize 100 .5 | gray | braille", 1.5, "resize 150 .5 | gray | braille"},
+		{"gray | resize 10 | ascii", 0.01, "gray | resize 1 | ascii"},
+		{"gray | braille", 2, "resize 640 | gray | braille"},
+		{"gray | braille", 1, "gray | braille"},
+		{"resize 0 .5 | gray | resize 0 | braille", 2, "resize 640 .5 | gray | resize 640 | braille"},
+		{"resize 100 | resize 0 2", .5, "resize 50 | resize 50 2"},
+	} {
+		if got := pipeline.MustParse(tt.pipeline).Scaled(tt.factor, 320).String(); got != tt.want {
+			t.Errorf("%q scaled by %g = %q, want %q", tt.pipeline, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	got, err := pipeline.DefaultOptions.Pipeline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != pipeline.Default {
+		t.Errorf("DefaultOptions make %q, want %q", got, pipeline.Default)
+	}
+
+	opts := pipeline.Options{Renderer: "ascii", Width: 80, Aspect: 2, Palette: " #"}
+	if got, _ := opts.Pipeline(); got.String() != `resize 80 0.5 | gray | ascii " #"` {
+		t.Errorf("ascii options make %q", got)
+	}
+
+	// Taller cells take fewer rows of the image, with or without a width.
+	for _, tt := range []struct {
+		width  int
+		aspect float64
+		want   string
+	}{
+		{40, 2, "resize 80 1"},
+		{40, 2.5, "resize 80 0.8"},
+		{0, 2.5, "resize 0 0.8"},
+		{0, 1, "resize 0 2"},
+	} {
+		opts := pipeline.Options{Renderer: "braille", Width: tt.width, Aspect: tt.aspect}
+		got, err := opts.Pipeline()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0].String() != tt.want {
+			t.Errorf("braille options of width %d and aspect %g start with %q, want %q", tt.width, tt.aspect, got[0], tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	goio "io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+	"github.com/IJJA3141/GoSCII/pipeline"
+	"github.com/IJJA3141/GoSCII/tui"
+	"golang.org/x/term"
+)
+
+// defaultRate is the frame rate of streams that do not give theirs.
+const defaultRate = 25
+
+// parseSize parses the WIDTHxHEIGHT size of raw frames.
+func parseSize(size string) (width, height int, err error) {
+	w, h, ok := strings.Cut(size, "x")
+	if width, err = strconv.Atoi(w); err == nil && ok {
+		height, err = strconv.Atoi(h)
+	}
+	if err != nil || !ok || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q, want WIDTHxHEIGHT", size)
+	}
+
+	return width, height, nil
+}
+
+// play renders the frames of stream and sends them to updates at rate,
+// starting after first. Frames whose time has passed by the time they would
+// be rendered are dropped, so that playback keeps up with the stream when
+// rendering or drawing is too slow.
+func play(stream *io.Stream, pipe *pipeline.Temporal, rate float64, updates chan<- tui.Update) {
+	defer close(updates)
+
+	period := time.Duration(float64(time.Second) / rate)
+	start := time.Now()
+	dropped := 0
+
+	for frame := 1; ; frame++ {
+		due := start.Add(time.Duration(frame) * period)
+
+		var err error
+		for time.Since(due) > period && err == nil {
+			if err = stream.Skip(); err == nil {
+				frame, dropped = frame+1, dropped+1
+				due = due.Add(period)
+			}
+		}
+
+		var img *filters.RGBAPlane
+		if err == nil {
+			img, err = stream.Next()
+		}
+		if errors.Is(err, goio.EOF) {
+			return
+		}
+		if err != nil {
+			updates <- tui.Update{Err: err}
+			return
+		}
+
+		result, err := pipe.Run(context.Background(), img)
+		if err != nil {
+			updates <- tui.Update{Err: err}
+			return
+		}
+
+		time.Sleep(time.Until(due))
+		updates <- tui.Update{
+			Frames: []filters.Ascii{result.Art},
+			Delays: []time.Duration{0},
+			Status: fmt.Sprintf(" frame %d, %s, %d dropped", frame+1, (time.Duration(frame) * period).Round(time.Second), dropped),
+		}
+	}
+}
+
+func runPlay(args []string) error {
+	var src source
+	var size string
+	var rate float64
+	var binds bindings
+	options := tui.DefaultOptions
+
+	set := newFlags("play", "", "Plays a raw video stream in the viewer, each frame going through the pipeline\nat the rate of the stream. Frames are dropped when rendering falls behind.\nWhen stdout is not a terminal, the first frame is written whole and the\nothers as the cells that changed, with the cursor moves to them.\n\nStreams are either YUV4MPEG2, as written by\n\n    ffmpeg -i video.mp4 -f yuv4mpegpipe - | goscii play\n\nor packed RGB24 frames of the size given by -size, as written by\n\n    ffmpeg -i video.mp4 -f rawvideo -pix_fmt rgb24 - | goscii play -size 640x360")
+	set.String(&src.in, "in", "i", io.Stdio, "path to the stream, - for stdin")
+	src.registerPipeline(set)
+	set.String(&size, "size", "s", "", "WIDTHxHEIGHT of the frames of RGB24 streams")
+	set.Float(&rate, "rate", "", 0, "frames per second, 0 for the rate of the stream")
+	registerViewer(set, &options, &binds)
+	if err := parse(set, args); err != nil {
+		return err
+	}
+
+	if err := setupViewer(&options, &binds); err != nil {
+		return err
+	}
+	if rate < 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+
+	var raw io.Raw
+	if size != "" {
+		var err error
+		if raw.Width, raw.Height, err = parseSize(size); err != nil {
+			return err
+		}
+	}
+
+	stages, err := src.pipe()
+	if err != nil {
+		return err
+	}
+	pipe := &pipeline.Temporal{Pipeline: stages, Hysteresis: src.hysteresis}
+
+	stream, err := io.OpenStream(src.in, raw)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if rate == 0 {
+		rate = stream.Rate
+	}
+	if rate == 0 {
+		rate = defaultRate
+	}
+
+	filters.SetWorkers(src.workers)
+	filters.DefaultEncoder.Profile = src.profile()
+
+	img, err := stream.Next()
+	if err != nil {
+		return err
+	}
+	first, err := pipe.Run(context.Background(), img)
+	if err != nil {
+		return err
+	}
+
+	updates := make(chan tui.Update)
+	go play(stream, pipe, rate, updates)
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		// Only the cells that changed are written, cursor moves skipping
+		// the others.
+		last := first.Art.(filters.Cells)
+		fmt.Print(filters.DefaultEncoder.EncodeDiff(nil, last))
+		for update := range updates {
+			if update.Err != nil {
+				return update.Err
+			}
+
+			next := update.Frames[0].(filters.Cells)
+			fmt.Print(filters.DefaultEncoder.EncodeDiff(last, next))
+			last = next
+		}
+		fmt.Printf("\x1b[%dH\n", last.Height_())
+		return nil
+	}
+
+	options.Updates, options.Pipeline = updates, stages
+	tui.Play([]filters.Ascii{first.Art}, []time.Duration{0}, -1, options)
+	return nil
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/io"
+	"github.com/IJJA3141/GoSCII/pipeline"
+	"github.com/IJJA3141/GoSCII/tui"
+	"github.com/IJJA3141/GoSCII/watch"
+	"golang.org/x/term"
+)
+
+// source is the input and the pipeline shared by render, view and convert.
+type source struct {
+	in           string
+	pipeline     string
+	pipelineFile string
+	options      pipeline.Options
+	color        string
+	workers      int
+	hysteresis   float64
+}
+
+func (this *source) register(set *io.Flags) {
+	set.String(&this.in, "in", "i", "./example_images/test_uwu.png", "path to the input image or saved render (.txt, .ans, .json), - for stdin")
+	this.registerPipeline(set)
+}
+
+// registerPipeline registers the flags of the pipeline, without -in.
+func (this *source) registerPipeline(set *io.Flags) {
+	def := pipeline.DefaultOptions
+
+	set.String(&this.pipeline, "pipeline", "p", "", "filters the image goes through, replacing -renderer, -width, -aspect, -palette and -color")
+	set.String(&this.pipelineFile, "pipeline-file", "", "", "path to a file holding the pipeline, one stage per line, replacing -pipeline")
+	set.Enum(&this.options.Renderer, "renderer", "r", def.Renderer, []string{"braille", "ascii", "edges"}, "how pixels become characters")
+	set.Int(&this.options.Width, "width", "", def.Width, "width of the render in characters, 0 for one Braille cell per 2x4 pixels or 100 columns")
+	set.Float(&this.options.Aspect, "aspect", "", def.Aspect, "height of the cells of the terminal over their width")
+	set.String(&this.options.Palette, "palette", "", def.Palette, "characters of the ascii renderer, from the darkest to the brightest")
+	set.Enum(&this.color, "color", "", "auto", []string{"auto", "truecolor", "256", "16", "none"}, "colors of the render, auto detecting what the terminal supports")
+	set.Int(&this.workers, "workers", "w", runtime.GOMAXPROCS(0), "number of goroutines the filters run on")
+	set.Float(&this.hysteresis, "hysteresis", "", 0, "gray levels the pixels of animations have to change by before their character does, 0 rendering every frame on its own")
+}
+
+// profile returns the color profile of the terminal -color asks for.
+func (this *source) profile() filters.Profile {
+	switch this.color {
+	case "truecolor":
+		return filters.TrueColor
+	case "256":
+		return filters.ANSI256
+	case "16":
+		return filters.ANSI16
+	case "none":
+		return filters.NoColor
+	}
+
+	return filters.DetectProfile()
+}
+
+// rendered is every frame of a render along with what it comes from.
+type rendered struct {
+	frames    []filters.Ascii
+	delays    []time.Duration
+	loopCount int
+
+	// gray is the grayscale image of the first frame, nil for saved renders.
+	gray *filters.GrayScalePlane
+	meta io.Metadata
+
+	// anim, pipe and hysteresis are what the frames were rendered from and
+	// with, anim being nil for saved renders.
+	anim       *io.Animation
+	pipe       pipeline.Pipeline
+	hysteresis float64
+}
+
+// rerender renders the frames again through pipe, factor times as large.
+func (this *rendered) rerender(ctx context.Context, pipe pipeline.Pipeline, factor float64) (tui.Update, error) {
+	if this.anim == nil {
+		return tui.Update{}, fmt.Errorf("saved renders cannot be rendered again")
+	}
+
+	update := tui.Update{Frames: make([]filters.Ascii, len(this.frames)), Delays: this.delays, LoopCount: this.loopCount}
+	temporal := pipeline.Temporal{Pipeline: pipe.Scaled(factor, this.anim.Width), Hysteresis: this.hysteresis}
+	for i, frame := range this.anim.Frames() {
+		result, err := temporal.Run(ctx, frame.Image)
+		if err != nil {
+			return tui.Update{}, err
+		}
+		update.Frames[i] = result.Art
+	}
+
+	return update, nil
+}
+
+// load renders every frame of the input. Saved renders are loaded as they
+// are. The filters run on the workers the command set once at startup, as
+// -watch loads again while zoomed renders may be running.
+func (this *source) load() (*rendered, error) {
+	if io.IsArt(this.in) {
+		return loadArt(this.in)
+	}
+
+	pipe, err := this.pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	return renderFile(this.in, pipe, this.hysteresis)
+}
+
+// pipe returns the pipeline given by -pipeline-file, -pipeline or the render
+// options, in that order.
+func (this *source) pipe() (pipeline.Pipeline, error) {
+	if this.pipelineFile != "" {
+		text, err := os.ReadFile(this.pipelineFile)
+		if err != nil {
+			return nil, err
+		}
+		return pipeline.Parse(string(text))
+	}
+
+	if this.pipeline != "" {
+		return pipeline.Parse(this.pipeline)
+	}
+
+	options := this.options
+	options.Color = this.color != "none"
+	return options.Pipeline()
+}
+
+// loadArt loads the saved render at path.
+func loadArt(path string) (*rendered, error) {
+	plane, meta, err := io.ReadArt(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rendered{frames: []filters.Ascii{plane}, delays: []time.Duration{0}, loopCount: -1, meta: meta}, nil
+}
+
+// renderFile runs every frame of the image at path through pipe, with
+// hysteresis between successive frames.
+func renderFile(path string, pipe pipeline.Pipeline, hysteresis float64) (*rendered, error) {
+	anim, err := io.ReadFrames(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &rendered{
+		frames:    make([]filters.Ascii, anim.Len()),
+		delays:    make([]time.Duration, anim.Len()),
+		loopCount: anim.LoopCount,
+		meta: io.Metadata{
+			Source:   path,
+			Width:    anim.Width,
+			Height:   anim.Height,
+			Pipeline: pipe.String(),
+			Created:  time.Now(),
+		},
+		anim:       anim,
+		pipe:       pipe,
+		hysteresis: hysteresis,
+	}
+
+	temporal := pipeline.Temporal{Pipeline: pipe, Hysteresis: hysteresis}
+	for i, frame := range anim.Frames() {
+		result, err := temporal.Run(context.Background(), frame.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			out.gray = result.Gray
+		}
+		out.frames[i], out.delays[i] = result.Art, frame.Delay
+	}
+
+	return out, nil
+}
+
+// printRender writes render to stdout, animations being printed as their
+// first frame.
+func printRender(render *rendered) {
+	frame := render.frames[0]
+	for _, line := range frame.Get(0, 0, frame.Width_(), frame.Height_()) {
+		fmt.Println(line)
+	}
+}
+
+func runRender(args []string) error {
+	var src source
+
+	set := newFlags("render", "", "Renders the input and prints it to stdout with the colors the terminal supports.")
+	src.register(set)
+	if err := parse(set, args); err != nil {
+		return err
+	}
+
+	filters.SetWorkers(src.workers)
+	render, err := src.load()
+	if err != nil {
+		return err
+	}
+
+	filters.DefaultEncoder.Profile = src.profile()
+	printRender(render)
+	return nil
+}
+
+// bindings are the colors and the keys of the viewer: the [theme] and [keys]
+// tables of the config file, whose values -theme and -key replace.
+type bindings struct {
+	theme, keys map[string]string
+}
+
+// registerViewer registers the flags of the viewer.
+func registerViewer(set *io.Flags, options *tui.Options, binds *bindings) {
+	binds.theme, binds.keys = maps.Clone(cfg.Theme), maps.Clone(cfg.Keys)
+
+	set.Int(&options.MenuWidth, "menu-width", "m", options.MenuWidth, "width of the menu on the right of the viewer")
+	set.Bool(&options.FullRedraw, "full-redraw", "", false, "draw through bubbletea's renderer, rewriting whole lines instead of the cells that changed")
+	set.Map(&binds.theme, "theme", "", "color of the status line, such as normal-bg=#646464, replacing that of the [theme] table")
+	set.Map(&binds.keys, "key", "", "key of an action of the viewer, such as left=a, replacing that of the [keys] table")
+}
+
+// setupViewer checks the flags of the viewer and applies the theme and the
+// key bindings.
+func setupViewer(options *tui.Options, binds *bindings) error {
+	if options.MenuWidth < 10 {
+		return fmt.Errorf("menu-width must be at least 10")
+	}
+	for key, value := range binds.theme {
+		if err := options.Theme.Set(key, value); err != nil {
+			return err
+		}
+	}
+	for action, key := range binds.keys {
+		if err := options.Bind(action, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runView(args []string) error {
+	var src source
+	var watching bool
+	var debounce time.Duration
+	var binds bindings
+	options := tui.DefaultOptions
+
+	set := newFlags("view", "", "Renders the input and opens it in the viewer, playing animations. The render\nis printed instead when stdout is not a terminal.\n\nWith -watch, the input and -pipeline-file are rendered again whenever they\nchange, the viewer keeping its position.\n\n'+' and '-' render the input again at a larger or smaller scale around\nthe center of the view, '=' fitting it to the window.\n\nIn INSERT mode, entered with 'i', the menu edits the pipeline, the input being\nrendered again on every change: up and down move the cursor, left and right\nchange the stage under it or the number, by ten with shift, keys and\nbackspace edit text, enter adds a stage and delete removes it. Esc goes back\nto NORMAL mode.\n\nThe [theme] and [keys] tables of the config file, and -theme and -key, set\nthe colors of the status line, such as normal-fg = \"#00ff00\", and the keys of\nthe viewer, such as left = \"a\". The actions are left, right, up, down,\ntheir -fast variants, visual, insert, command, pause, step, step-back, loop,\nzoom-in, zoom-out and fit.")
+	src.register(set)
+	registerViewer(set, &options, &binds)
+	set.Bool(&watching, "watch", "", false, "render the input again when it or -pipeline-file changes")
+	set.Duration(&debounce, "debounce", "", 100*time.Millisecond, "how long files have to stay untouched before -watch renders them")
+	if err := parse(set, args); err != nil {
+		return err
+	}
+
+	if err := setupViewer(&options, &binds); err != nil {
+		return err
+	}
+
+	filters.SetWorkers(src.workers)
+	render, err := src.load()
+	if err != nil {
+		return err
+	}
+
+	filters.DefaultEncoder.Profile = src.profile()
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		printRender(render)
+		return nil
+	}
+
+	// current is the render zoomed into and edited, replaced by those of
+	// -watch.
+	var current atomic.Pointer[rendered]
+	current.Store(render)
+	options.Gray = render.gray
+	if render.anim != nil {
+		options.Pipeline = render.pipe
+		options.Render = func(ctx context.Context, pipe pipeline.Pipeline, factor float64) (tui.Update, error) {
+			return current.Load().rerender(ctx, pipe, factor)
+		}
+	}
+
+	if watching {
+		paths := []string{src.in}
+		if src.pipelineFile != "" {
+			paths = append(paths, src.pipelineFile)
+		}
+
+		watcher, err := watch.New(paths, debounce)
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+
+		options.Updates = reload(&src, watcher, &current)
+	}
+
+	tui.Play(render.frames, render.delays, render.loopCount, options)
+	return nil
+}
+
+// reload renders src again whenever watcher reports a change, storing the
+// render in current before sending it.
+func reload(src *source, watcher *watch.Watcher, current *atomic.Pointer[rendered]) <-chan tui.Update {
+	updates := make(chan tui.Update)
+
+	go func() {
+		defer close(updates)
+
+		for range watcher.C {
+			render, err := src.load()
+			if err != nil {
+				updates <- tui.Update{Err: err}
+				continue
+			}
+
+			current.Store(render)
+			updates <- tui.Update{Frames: render.frames, Delays: render.delays, LoopCount: render.loopCount, Pipeline: render.pipe}
+		}
+	}()
+
+	return updates
+}
+
+// output is the encoding of the images convert and batch write.
+type output struct {
+	format, compression string
+	quality             int
+}
+
+func (this *output) register(set *io.Flags) {
+	set.Enum(&this.format, "format", "f", "", []string{"png", "jpeg", "jpg", "gif", "ppm"}, "image format of the images written, given by their extension by default")
+	set.Int(&this.quality, "quality", "q", 90, "JPEG quality, from 1 to 100")
+	set.Enum(&this.compression, "compression", "c", "default", []string{"default", "none", "fast", "best"}, "PNG compression level")
+}
+
+// options returns the options the images are written with.
+func (this *output) options() (io.WriteOptions, error) {
+	image := io.WriteOptions{Quality: this.quality}
+
+	var err error
+	if this.format != "" {
+		if image.Format, err = io.ParseFormat(this.format); err != nil {
+			return image, err
+		}
+	}
+	image.Compression, err = io.ParseCompression(this.compression)
+
+	return image, err
+}
+
+func runConvert(args []string) error {
+	var src source
+	var enc output
+	var out, dither string
+
+	set := newFlags("convert", "", "Renders the input and writes it to a file, animations being written as their\nfirst frame.")
+	src.register(set)
+	set.String(&out, "out", "o", "out.png", "path to the rendered image, PNG, JPEG, GIF, .svg, .html, .txt, .ans or .json, - for stdout")
+	set.String(&dither, "dither", "d", "", "path to the grayscale image the render is made of")
+	enc.register(set)
+	if err := parse(set, args); err != nil {
+		return err
+	}
+
+	image, err := enc.options()
+	if err != nil {
+		return err
+	}
+
+	filters.SetWorkers(src.workers)
+	render, err := src.load()
+	if err != nil {
+		return err
+	}
+
+	opts := io.DefaultExportOptions
+	opts.Metadata, opts.Image = render.meta, image
+
+	if err := io.WriteASCII(out, render.frames[0].(io.Cells), opts); err != nil {
+		return err
+	}
+
+	if dither != "" && render.gray != nil {
+		return io.Write(dither, render.gray.ToRGBA(), image)
+	}
+
+	return nil
+}
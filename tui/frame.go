@@ -114,14 +114,78 @@ func (this *frame) Resize(width, height int) {
 	this.hasChanged = true
 }
 
+// SetImage replaces the image while keeping the viewport, clamped to the new
+// image when it is smaller.
 func (this *frame) SetImage(image filters.Ascii) {
-	this.x = 0
-	this.y = 0
 	this.src = image
+	this.x = max(0, min(this.x, image.Width_()-this.width))
+	this.y = max(0, min(this.y, image.Height_()-this.height))
+
+	this.hasChanged = true
+}
+
+// Center returns the point of the image at the center of the viewport, as a
+// fraction of its width and height.
+func (this *frame) Center() (float64, float64) {
+	width, height := float64(this.src.Width_()), float64(this.src.Height_())
+	if width == 0 || height == 0 {
+		return .5, .5
+	}
+
+	x, y := width/2, height/2
+	if width > float64(this.width) {
+		x = float64(this.x) + float64(this.width)/2
+	}
+	if height > float64(this.height) {
+		y = float64(this.y) + float64(this.height)/2
+	}
+
+	return x / width, y / height
+}
+
+// CenterOn moves the viewport over the point of the image given as by Center,
+// as far as the image allows.
+func (this *frame) CenterOn(x, y float64) {
+	width, height := this.src.Width_(), this.src.Height_()
+	this.x = max(0, min(int(math.Round(x*float64(width)-float64(this.width)/2)), width-this.width))
+	this.y = max(0, min(int(math.Round(y*float64(height)-float64(this.height)/2)), height-this.height))
 
 	this.hasChanged = true
 }
 
+// Cells returns the cells of the viewport, row by row, the margins around
+// images smaller than the frame being blank. It returns nil when the image
+// cannot be read cell by cell.
+func (this *frame) Cells() []filters.Cell {
+	src, ok := this.src.(filters.Cells)
+	if !ok {
+		return nil
+	}
+
+	// The margins are those View gives, rounded down on the left and top.
+	left, top := -this.x, -this.y
+	if width := src.Width_(); width < this.width {
+		left = (this.width - width) / 2
+	}
+	if height := src.Height_(); height < this.height {
+		top = (this.height - height) / 2
+	}
+
+	out := make([]filters.Cell, this.width*this.height)
+	for j := range this.height {
+		for i := range this.width {
+			x, y := i-left, j-top
+			if x < 0 || y < 0 || x >= src.Width_() || y >= src.Height_() {
+				out[j*this.width+i] = filters.Cell{Rune: ' '}
+			} else {
+				out[j*this.width+i] = src.At(x, y)
+			}
+		}
+	}
+
+	return out
+}
+
 func (this *frame) View() []string {
 	if this.hasChanged {
 
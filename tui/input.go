@@ -1,44 +1,265 @@
 package tui
 
 import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/IJJA3141/GoSCII/pipeline"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// editor lists the stages of the pipeline and their parameters, one per
+// line. In INSERT mode, up and down move the cursor; on a stage, left and
+// right change the stage; on a parameter, they decrease and increase numbers
+// while typed keys and backspace edit the value. Enter adds a stage after the
+// one under the cursor and delete removes it.
 type editor struct {
 	width, height int
+
+	// stages hold every argument of their stage, defaults included, so that
+	// each can be edited.
+	stages []pipeline.Stage
+
+	// row is the line under the cursor, top the first line shown.
+	row, top int
+	focus    bool
+}
+
+// line is a line of the editor: a stage, or one of its parameters when param
+// is not -1.
+type line struct {
+	stage, param int
+}
+
+func Editor(pipe pipeline.Pipeline) editor {
+	this := editor{}
+	this.SetPipeline(pipe)
+	return this
+}
+
+// SetPipeline replaces the stages edited by those of pipe.
+func (this *editor) SetPipeline(pipe pipeline.Pipeline) {
+	this.stages = make([]pipeline.Stage, len(pipe))
+	for i, stage := range pipe {
+		this.stages[i] = filled(stage)
+	}
+
+	this.row = min(this.row, max(0, len(this.lines())-1))
+	this.scroll()
+}
+
+// filled returns stage with every argument given, defaults included.
+func filled(stage pipeline.Stage) pipeline.Stage {
+	params := pipeline.Params(stage.Name)
+	args := make([]string, len(params))
+	for i := range params {
+		args[i] = stage.Arg(i)
+	}
+
+	return pipeline.Stage{Name: stage.Name, Args: args}
+}
+
+// Pipeline returns the pipeline edited, or the error of its first invalid
+// stage.
+func (this *editor) Pipeline() (pipeline.Pipeline, error) {
+	out := make(pipeline.Pipeline, len(this.stages))
+	for i, stage := range this.stages {
+		if err := stage.Check(); err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		out[i] = pipeline.Stage{Name: stage.Name, Args: slices.Clone(stage.Args)}
+	}
+
+	return out, nil
 }
 
-func Editor() editor {
-	return editor{}
+// lines returns the lines of the editor, each stage followed by its
+// parameters.
+func (this *editor) lines() []line {
+	var out []line
+	for i, stage := range this.stages {
+		out = append(out, line{i, -1})
+		for j := range stage.Args {
+			out = append(out, line{i, j})
+		}
+	}
+
+	return out
 }
 
 func (this *editor) View() []string {
 	out := make([]string, this.height)
-	empty := strings.Repeat(" ", this.width)
+	lines := this.lines()
 
 	for i := range out {
-		out[i] = empty
+		n := this.top + i
+		if n >= len(lines) {
+			if len(lines) == 0 && i == 0 {
+				out[i] = fit(" no pipeline", this.width)
+			} else {
+				out[i] = strings.Repeat(" ", this.width)
+			}
+			continue
+		}
+
+		stage := this.stages[lines[n].stage]
+		var text string
+		if param := lines[n].param; param == -1 {
+			text = fmt.Sprintf(" %d %s", lines[n].stage+1, stage.Name)
+		} else {
+			spec := pipeline.Params(stage.Name)[param]
+			value := stage.Args[param]
+			if !spec.Number {
+				value = `"` + value + `"`
+			}
+			text = fmt.Sprintf("     %-10s %s", spec.Name, value)
+		}
+
+		out[i] = fit(text, this.width)
+		if this.focus && n == this.row {
+			out[i] = "\x1b[7m" + out[i] + "\x1b[0m"
+		}
 	}
 
 	return out
 }
 
-func (this *editor) Update(msg tea.KeyMsg) {
+// scroll moves the lines shown for the cursor to be among them.
+func (this *editor) scroll() {
+	this.top = max(0, min(this.top, this.row, len(this.lines())-this.height))
+	if this.row >= this.top+this.height {
+		this.top = this.row - this.height + 1
+	}
+}
+
+// Update applies msg and reports whether it changed the pipeline.
+func (this *editor) Update(msg tea.KeyMsg) bool {
+	defer this.scroll()
+	lines := this.lines()
+
+	switch msg.String() {
+	case "up":
+		this.row = max(0, this.row-1)
+		return false
+
+	case "down":
+		this.row = max(0, min(this.row+1, len(lines)-1))
+		return false
+
+	case "enter":
+		// The new stage goes after the one under the cursor, its parameters
+		// included.
+		at := 0
+		if len(lines) > 0 {
+			at = lines[this.row].stage + 1
+		}
+		this.stages = slices.Insert(this.stages, at, filled(pipeline.Stage{Name: "gray"}))
+
+		this.row = slices.Index(this.lines(), line{at, -1})
+		return true
+	}
+
+	if len(lines) == 0 {
+		return false
+	}
+
+	current := lines[this.row]
+	stage := &this.stages[current.stage]
+
+	switch msg.String() {
+	case "delete", "ctrl+d":
+		this.stages = slices.Delete(this.stages, current.stage, current.stage+1)
+
+		// The cursor goes to the stage that took its place.
+		lines = this.lines()
+		this.row = slices.Index(lines, line{min(current.stage, len(this.stages)-1), -1})
+		this.row = max(0, this.row)
+		return true
+
+	case "left", "right", "shift+left", "shift+right":
+		by := 1
+		if strings.HasSuffix(msg.String(), "left") {
+			by = -1
+		}
+		if strings.HasPrefix(msg.String(), "shift+") {
+			by *= 10
+		}
+
+		if current.param == -1 {
+			names := pipeline.Names()
+			i := slices.Index(names, stage.Name)
+			*stage = filled(pipeline.Stage{Name: names[((i+by)%len(names)+len(names))%len(names)]})
+			return true
+		}
+
+		param := pipeline.Params(stage.Name)[current.param]
+		if !param.Number {
+			return false
+		}
+		value, ok := step(stage.Args[current.param], by, param)
+		stage.Args[current.param] = value
+		return ok
+
+	case "backspace":
+		if current.param == -1 {
+			return false
+		}
+
+		runes := []rune(stage.Args[current.param])
+		if len(runes) == 0 {
+			return false
+		}
+		stage.Args[current.param] = string(runes[:len(runes)-1])
+		return true
+	}
+
+	if current.param == -1 || (msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace) {
+		return false
+	}
+
+	stage.Args[current.param] += string(msg.Runes)
+	return true
+}
+
+// step adds by units of its last digit to value, 0.1 to 0.5 and 1 to 8,
+// keeping it within the bounds of param. It reports false when value is not
+// a number or is already at the bound.
+func step(value string, by int, param pipeline.Param) (string, bool) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value, false
+	}
+
+	decimals := 0
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		decimals = len(value) - i - 1
+	}
+
+	next := v + float64(by)*math.Pow10(-decimals)
+	if param.Bounded() && (next < param.Min || next > param.Max) {
+		// The bound may have more decimals than value, such as 0.01.
+		next = max(param.Min, min(next, param.Max))
+		decimals = -1
+	}
+	if next == v {
+		return value, false
+	}
+
+	return strconv.FormatFloat(next, 'f', decimals, 64), true
 }
 
 func (this *editor) Height(height int) {
 	this.height = height
+	this.scroll()
 }
 
 func (this *editor) Width(width int) {
 	this.width = width
 }
 
-func (this *editor) AddLine() {
-}
-
 type command struct {
 	width int
 	focus bool
@@ -65,7 +286,7 @@ func (this *command) Update(msg tea.KeyMsg) {
 	switch msg.String() {
 	case tea.KeyBackspace.String():
 		this.cmd = this.cmd[:max(0, len(this.cmd)-1)]
-	
+
 	default:
 		this.cmd += msg.String()
 	}
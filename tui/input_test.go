@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/pipeline"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// key returns the message of the key called name as bubbletea names it, or
+// of the runes typed.
+func key(name string) tea.KeyMsg {
+	for k, n := range map[tea.KeyType]string{
+		tea.KeyUp: "up", tea.KeyDown: "down", tea.KeyLeft: "left", tea.KeyRight: "right",
+		tea.KeyShiftLeft: "shift+left", tea.KeyShiftRight: "shift+right",
+		tea.KeyEnter: "enter", tea.KeyDelete: "delete", tea.KeyBackspace: "backspace",
+	} {
+		if n == name {
+			return tea.KeyMsg{Type: k}
+		}
+	}
+
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+func TestEditor(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		pipeline string
+		keys     []string
+		// changes are the keys reporting a change of the pipeline.
+		changes int
+		want    string
+		row     int
+	}{
+		{"cursor", "gray | dither", []string{"up", "down", "down", "down", "down"}, 0, "gray | dither 8", 2},
+		{"decrease", "gray | dither", []string{"down", "down", "left"}, 1, "gray | dither 7", 2},
+		{"upper bound", "gray | dither 7", []string{"down", "down", "shift+right", "right"}, 1, "gray | dither 8", 2},
+		{"lower bound", "dither 3", []string{"down", "shift+left", "left"}, 1, "dither 1", 1},
+		{"decimals", "resize 80 0.5", []string{"down", "down", "right", "shift+left", "shift+left"}, 2, "resize 80 0.01", 2},
+		{"unbounded", "edges 200", []string{"down", "shift+right", "right"}, 2, `edges 211 "|/-\\|/-\\|"`, 1},
+		{"text", `ascii "ab"`, []string{"down", "right", "backspace", "c", " "}, 3, `ascii "ac "`, 1},
+		{"typed number", "braille 200", []string{"down", "backspace", "backspace", "5"}, 3, "braille 25", 1},
+		{"next stage", "gray", []string{"right"}, 1, "invert", 0},
+		{"previous stage", "gray | braille", []string{"down", "left"}, 1, "gray | dither 8", 1},
+		{"last stage", "gray", []string{"left", "left"}, 2, "colorize", 0},
+		{"add", "invert | dither", []string{"enter"}, 1, "invert | gray | dither 8", 1},
+		{"add to nothing", "", []string{"down", "enter"}, 1, "gray", 0},
+		{"delete", "gray | dither 4 | braille", []string{"down", "down", "delete"}, 1, "gray | braille 128", 1},
+		{"delete last", "gray | dither 4", []string{"down", "down", "delete"}, 1, "gray", 0},
+		{"stage keys", "gray", []string{"backspace", "x"}, 0, "gray", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var pipe pipeline.Pipeline
+			if tt.pipeline != "" {
+				pipe = pipeline.MustParse(tt.pipeline)
+			}
+
+			e := Editor(pipe)
+			e.Height(20)
+
+			changes := 0
+			for _, name := range tt.keys {
+				if e.Update(key(name)) {
+					changes++
+				}
+			}
+
+			got, err := e.Pipeline()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("pipeline = %q, want %q", got, tt.want)
+			}
+			if changes != tt.changes {
+				t.Errorf("%d keys changed the pipeline, want %d", changes, tt.changes)
+			}
+			if e.row != tt.row {
+				t.Errorf("cursor on line %d, want %d", e.row, tt.row)
+			}
+		})
+	}
+}
+
+func TestEditorInvalid(t *testing.T) {
+	e := Editor(pipeline.MustParse("dither 4"))
+	e.Height(20)
+
+	for _, name := range []string{"down", "backspace", "9"} {
+		e.Update(key(name))
+	}
+	if _, err := e.Pipeline(); err == nil {
+		t.Error("dither 9 was accepted")
+	}
+
+	e.Update(key("backspace"))
+	if _, err := e.Pipeline(); err == nil {
+		t.Error("dither without order was accepted")
+	}
+}
+
+func TestStep(t *testing.T) {
+	order := pipeline.Params("dither")[0]
+	ratio := pipeline.Params("resize")[1]
+	threshold := pipeline.Params("edges")[0]
+
+	for _, tt := range []struct {
+		value string
+		by    int
+		param pipeline.Param
+		want  string
+		ok    bool
+	}{
+		{"4", 1, order, "5", true},
+		{"8", 1, order, "8", false},
+		{"7", 10, order, "8", true},
+		{"1", -1, order, "1", false},
+		{"0.5", 1, ratio, "0.6", true},
+		{"0.05", -10, ratio, "0.01", true},
+		{"99.5", 10, ratio, "100", true},
+		{"200", -10, threshold, "190", true},
+		{"-3", -10, threshold, "-13", true},
+		{"abc", 1, threshold, "abc", false},
+	} {
+		got, ok := step(tt.value, tt.by, tt.param)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("step(%q, %d) = %q, %v, want %q, %v", tt.value, tt.by, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/pipeline"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Theme holds the colors of the status line as r;g;b SGR parameters.
+type Theme struct {
+	Background string
+
+	NormalFG, NormalBG   string
+	VisualFG, VisualBG   string
+	InsertFG, InsertBG   string
+	CommandFG, CommandBG string
+}
+
+var DefaultTheme = Theme{
+	Background: "255;255;255",
+
+	NormalFG: "0;255;0", NormalBG: "100;100;100",
+	VisualFG: "0;155;0", VisualBG: "100;255;0",
+	InsertFG: "0;255;100", InsertBG: "0;255;255",
+	CommandFG: "0;255;200", CommandBG: "200;255;0",
+}
+
+// Set sets the color called key, such as normal-fg, to value, given as
+// #rrggbb or r;g;b.
+func (this *Theme) Set(key, value string) error {
+	fields := map[string]*string{
+		"background": &this.Background,
+		"normal-fg":  &this.NormalFG, "normal-bg": &this.NormalBG,
+		"visual-fg": &this.VisualFG, "visual-bg": &this.VisualBG,
+		"insert-fg": &this.InsertFG, "insert-bg": &this.InsertBG,
+		"command-fg": &this.CommandFG, "command-bg": &this.CommandBG,
+	}
+
+	field, ok := fields[key]
+	if !ok {
+		return fmt.Errorf("theme: unknown color %q", key)
+	}
+
+	color, err := parseColor(value)
+	if err != nil {
+		return fmt.Errorf("theme: %s: %w", key, err)
+	}
+
+	*field = color
+	return nil
+}
+
+// parseColor turns #rrggbb or r;g;b into r;g;b.
+func parseColor(value string) (string, error) {
+	if hex, ok := strings.CutPrefix(value, "#"); ok && len(hex) == 6 {
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err == nil {
+			return fmt.Sprintf("%d;%d;%d", v>>16, v>>8&0xff, v&0xff), nil
+		}
+	}
+
+	parts := strings.Split(value, ";")
+	if len(parts) == 3 {
+		for _, part := range parts {
+			if v, err := strconv.Atoi(part); err != nil || v < 0 || v > 255 {
+				return "", fmt.Errorf("invalid color %q", value)
+			}
+		}
+		return value, nil
+	}
+
+	return "", fmt.Errorf("invalid color %q", value)
+}
+
+// actions are the keys of NORMAL and VISUAL mode by the name they are bound
+// with.
+var actions = map[string]string{
+	"left": "h", "right": "l", "up": "k", "down": "j",
+	"left-fast": "H", "right-fast": "L", "up-fast": "K", "down-fast": "J",
+	"visual": "v", "insert": "i", "command": ":",
+	"pause": " ", "step": ".", "step-back": ",", "loop": "o",
+	"zoom-in": "+", "zoom-out": "-", "fit": "=",
+}
+
+// Options configures the viewer.
+type Options struct {
+	MenuWidth int
+	Theme     Theme
+
+	// FullRedraw draws through bubbletea's renderer, which writes every line
+	// that changed in full, instead of only the cells that changed.
+	FullRedraw bool
+
+	// Updates replaces the frames shown by those it receives, such as the
+	// renders of a watched file.
+	Updates <-chan Update
+
+	// Pipeline is the pipeline the frames were rendered with, listed by the
+	// editor.
+	Pipeline pipeline.Pipeline
+
+	// Gray is the grayscale image the frames were rendered from, which
+	// :invert inverts. The command does nothing when nil.
+	Gray *filters.GrayScalePlane
+
+	// Render renders the frames again through pipe, factor times as large as
+	// those given to the viewer, for zooming with '+', '-' and '=' and for
+	// the changes of the editor. Neither is possible when nil.
+	Render func(ctx context.Context, pipe pipeline.Pipeline, factor float64) (Update, error)
+
+	// keys maps the keys bound by Bind to the default keys of their
+	// actions, the empty string disabling a default key.
+	keys map[string]string
+}
+
+var DefaultOptions = Options{MenuWidth: 55, Theme: DefaultTheme}
+
+// Bind binds action, such as left or pause, to key, named as bubbletea does:
+// "a", "ctrl+a", "left" or " " for space. The default key of the action no
+// longer triggers it.
+func (this *Options) Bind(action, key string) error {
+	def, ok := actions[action]
+	if !ok {
+		return fmt.Errorf("keys: unknown action %q", action)
+	}
+
+	keys := make(map[string]string, len(this.keys)+2)
+	for k, v := range this.keys {
+		keys[k] = v
+	}
+	if _, bound := keys[def]; !bound {
+		keys[def] = ""
+	}
+	keys[key] = def
+
+	this.keys = keys
+	return nil
+}
+
+// translate returns the key msg stands for once the bindings applied.
+func (this *Options) translate(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	def, ok := this.keys[msg.String()]
+	switch {
+	case !ok:
+		return msg, true
+	case def == "":
+		return msg, false
+	case def == " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, true
+	}
+
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(def)}, true
+}
+
+// Update is a new render for the viewer, or the error that prevented it.
+type Update struct {
+	Frames []filters.Ascii
+	Delays []time.Duration
+	// LoopCount follows image/gif: 0 loops forever, -1 plays the frames once
+	// and n plays them n+1 times.
+	LoopCount int
+	// Status is shown on the last line of the menu, such as the position in
+	// a video.
+	Status string
+	// Pipeline replaces the pipeline of the editor when not nil.
+	Pipeline pipeline.Pipeline
+
+	Err error
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// player steps through the frames of an animation.
+type player struct {
+	frames []filters.Ascii
+	delays []time.Duration
+	index  int
+
+	paused bool
+
+	// loop plays the animation forever, 'o' toggling it. Otherwise it is
+	// played plays times, played counting those that reached its end.
+	loop          bool
+	plays, played int
+
+	// ticks is bumped on pause and step, invalidating the tick in flight.
+	ticks int
+}
+
+// tickMsg advances the player whose tick counter is still id.
+type tickMsg struct {
+	id int
+}
+
+// Player returns the player of frames, which loopCount, as in image/gif,
+// plays forever when 0, once when -1 and n+1 times otherwise.
+func Player(frames []filters.Ascii, delays []time.Duration, loopCount int) player {
+	return player{frames: frames, delays: delays, loop: loopCount == 0, plays: max(1, loopCount+1)}
+}
+
+// Animated reports whether there is more than one frame to play.
+func (this *player) Animated() bool {
+	return len(this.frames) > 1
+}
+
+// Frame returns the current frame.
+func (this *player) Frame() filters.Ascii {
+	return this.frames[this.index]
+}
+
+// Tick schedules the next frame, if the animation is playing.
+func (this *player) Tick() tea.Cmd {
+	if !this.Animated() || this.paused {
+		return nil
+	}
+
+	id := this.ticks
+	return tea.Tick(this.delays[this.index], func(time.Time) tea.Msg {
+		return tickMsg{id: id}
+	})
+}
+
+// Advance moves to the next frame on msg and reports whether the frame
+// changed. Playback stops on the last frame of the last play when not
+// looping.
+func (this *player) Advance(msg tickMsg) bool {
+	if msg.id != this.ticks {
+		return false // tick of a paused or stepped animation
+	}
+
+	if this.index == len(this.frames)-1 && !this.loop {
+		this.played++
+		if this.played >= this.plays {
+			this.paused = true
+			return false
+		}
+	}
+
+	this.index = (this.index + 1) % len(this.frames)
+	return true
+}
+
+// Update handles the playback keys and reports whether the frame changed.
+func (this *player) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if !this.Animated() {
+		return false, nil
+	}
+
+	switch msg.String() {
+	case " ":
+		this.paused = !this.paused
+		this.ticks++
+
+		// Resuming a finished animation starts it over.
+		if !this.paused && !this.loop && this.index == len(this.frames)-1 && this.played >= this.plays {
+			this.index, this.played = 0, 0
+			return true, this.Tick()
+		}
+		return false, this.Tick()
+
+	case ".":
+		this.paused = true
+		this.ticks++
+		this.index = (this.index + 1) % len(this.frames)
+		return true, nil
+
+	case ",":
+		this.paused = true
+		this.ticks++
+		this.index = (this.index - 1 + len(this.frames)) % len(this.frames)
+		return true, nil
+
+	case "o":
+		this.loop = !this.loop
+	}
+
+	return false, nil
+}
+
+// Keys reports whether key is a playback key.
+func (this *player) Keys(key string) bool {
+	switch key {
+	case " ", ".", ",", "o":
+		return this.Animated()
+	}
+
+	return false
+}
+
+func (this *player) View() string {
+	state := "playing"
+	if this.paused {
+		state = "paused"
+	}
+
+	loop := ""
+	switch {
+	case this.loop:
+		loop = ", loop"
+	case this.plays > 1:
+		loop = fmt.Sprintf(", play %d/%d", min(this.played+1, this.plays), this.plays)
+	}
+
+	return fmt.Sprintf(" frame %d/%d %s%s", this.index+1, len(this.frames), state, loop)
+}
@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPlayerLoopCount(t *testing.T) {
+	frames := []filters.Ascii{filters.NewAsciiPlane(1, 1), filters.NewAsciiPlane(1, 1), filters.NewAsciiPlane(1, 1)}
+	delays := []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+
+	for _, tt := range []struct {
+		loopCount int
+		// frames is the number of frames shown before stopping, -1 for
+		// never stopping.
+		frames int
+	}{
+		{-1, 3},
+		{1, 6},
+		{3, 12},
+		{0, -1},
+	} {
+		p := Player(frames, delays, tt.loopCount)
+
+		shown := 1
+		for shown < 100 && p.Advance(tickMsg{id: p.ticks}) {
+			shown++
+		}
+
+		if tt.frames == -1 {
+			if shown != 100 || p.paused {
+				t.Errorf("loop count %d stopped after %d frames, want to loop forever", tt.loopCount, shown)
+			}
+			continue
+		}
+		if shown != tt.frames || !p.paused || p.index != len(frames)-1 {
+			t.Errorf("loop count %d showed %d frames, want %d and stopping on the last", tt.loopCount, shown, tt.frames)
+		}
+
+		// Resuming plays it as many times again.
+		if changed, _ := p.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}); !changed || p.index != 0 {
+			t.Fatalf("loop count %d: resuming a finished animation did not start it over", tt.loopCount)
+		}
+		shown = 1
+		for shown < 100 && p.Advance(tickMsg{id: p.ticks}) {
+			shown++
+		}
+		if shown != tt.frames {
+			t.Errorf("loop count %d showed %d frames when resumed, want %d", tt.loopCount, shown, tt.frames)
+		}
+	}
+}
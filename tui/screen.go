@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/term"
+)
+
+// screen draws the viewer by itself instead of through bubbletea's renderer,
+// which writes every line that changed in full: panning a truecolor render
+// changes them all. screen remembers what it drew and only writes the spans
+// of cells that changed, moving the cursor over the others.
+type screen struct {
+	// width and height are those of the terminal, frameWidth that of the
+	// frame on the left of the menu.
+	width, height, frameWidth int
+
+	// cells and menu are what was drawn, nil to redraw everything.
+	cells []filters.Cell
+	menu  []string
+}
+
+// grid reads a slice of cells as filters.Cells.
+type grid struct {
+	cells         []filters.Cell
+	width, height int
+}
+
+func (this grid) Width_() int              { return this.width }
+func (this grid) Height_() int             { return this.height }
+func (this grid) At(x, y int) filters.Cell { return this.cells[y*this.width+x] }
+
+// Draw returns the text turning the screen into cells, the frameWidth wide
+// frame, and menu, the lines on its right. Both have one row per line of the
+// terminal.
+func (this *screen) Draw(width, height, frameWidth int, cells []filters.Cell, menu []string) string {
+	if width != this.width || height != this.height || frameWidth != this.frameWidth {
+		this.width, this.height, this.frameWidth = width, height, frameWidth
+		this.cells, this.menu = nil, nil
+	}
+
+	var b strings.Builder
+
+	next := grid{cells, frameWidth, height}
+	if this.cells == nil {
+		b.WriteString(filters.DefaultEncoder.EncodeDiff(nil, next))
+	} else {
+		b.WriteString(filters.DefaultEncoder.EncodeDiff(grid{this.cells, frameWidth, height}, next))
+	}
+
+	for i, line := range menu {
+		if this.menu != nil && this.menu[i] == line {
+			continue
+		}
+
+		b.WriteString("\x1b[" + strconv.Itoa(i+1) + ";" + strconv.Itoa(frameWidth+1) + "H")
+		b.WriteString(ansi.Truncate(line, width-frameWidth, "") + "\x1b[0m")
+	}
+
+	this.cells, this.menu = cells, menu
+	return b.String()
+}
+
+// Reset makes the next Draw redraw everything.
+func (this *screen) Reset() {
+	this.cells, this.menu = nil, nil
+}
+
+// runDirect runs m with the screen drawing it. Without its renderer,
+// bubbletea leaves the terminal as it is and does not report its size, which
+// is done here instead.
+func runDirect(m model) error {
+	input := os.Stdin
+	if !term.IsTerminal(int(input.Fd())) {
+		// stdin carried the image, read keys from the terminal itself.
+		tty, err := openTTY()
+		if err != nil {
+			return err
+		}
+		defer tty.Close()
+		input = tty
+	}
+
+	state, err := term.MakeRaw(int(input.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(input.Fd()), state)
+
+	// Alternate screen, hidden cursor.
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	defer os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+
+	p := tea.NewProgram(m, tea.WithoutRenderer(), tea.WithInput(input))
+	stop := resizes(p)
+	defer stop()
+
+	_, err = p.Run()
+	return err
+}
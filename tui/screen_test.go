@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/IJJA3141/GoSCII/filters"
+)
+
+// runes returns the Braille runes of s, those the cells of the tests hold.
+func runes(s string) []rune {
+	var out []rune
+	for _, r := range s {
+		if r >= 0x2800 && r <= 0x28ff {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+func TestScreenDraw(t *testing.T) {
+	const width, height, frameWidth = 10, 3, 6
+
+	base := make([]filters.Cell, frameWidth*height)
+	for i := range base {
+		base[i] = filters.Cell{Rune: rune(0x2801 + i)}
+	}
+	menu := []string{"|one", "|two", "|six"}
+
+	// full are the cursor moves of a draw from scratch: clearing the screen,
+	// then every row of the frame and of the menu.
+	full := []string{"\x1b[H\x1b[2J", "\x1b[1;1H", "\x1b[2;1H", "\x1b[3;1H", "\x1b[1;7H", "\x1b[2;7H", "\x1b[3;7H"}
+
+	// changed returns base with the cells at indices replaced.
+	changed := func(indices ...int) []filters.Cell {
+		out := slices.Clone(base)
+		for _, i := range indices {
+			out[i].Rune = rune(0x28f0 + i)
+		}
+		return out
+	}
+
+	for _, tt := range []struct {
+		name  string
+		cells []filters.Cell
+		menu  []string
+		width int
+		reset bool
+
+		// moves are the cursor moves of the text drawn and runes the cells
+		// it writes.
+		moves []string
+		runes []rune
+	}{
+		{name: "unchanged", cells: base, menu: menu, width: width},
+		{
+			name: "one cell", cells: changed(8), menu: menu, width: width,
+			moves: []string{"\x1b[2;3H"}, runes: []rune{0x28f8},
+		},
+		{
+			// Unchanged cells shorter than a cursor move are written over.
+			name: "close cells", cells: changed(0, 3), menu: menu, width: width,
+			moves: []string{"\x1b[1;1H"}, runes: []rune{0x28f0, base[1].Rune, base[2].Rune, 0x28f3},
+		},
+		{
+			name: "cells of two rows", cells: changed(5, 12), menu: menu, width: width,
+			moves: []string{"\x1b[1;6H", "\x1b[3;1H"}, runes: []rune{0x28f5, 0x28fc},
+		},
+		{
+			name: "menu line", cells: base, menu: []string{"|one", "|ten", "|six"}, width: width,
+			moves: []string{"\x1b[2;7H"},
+		},
+		{
+			name: "resized", cells: base, menu: menu, width: width + 1,
+			moves: full, runes: runes(filters.DefaultEncoder.Encode(base)),
+		},
+		{
+			name: "reset", cells: base, menu: menu, width: width, reset: true,
+			moves: full, runes: runes(filters.DefaultEncoder.Encode(base)),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var s screen
+			if first := s.Draw(width, height, frameWidth, base, menu); !slices.Equal(runes(first), runes(filters.DefaultEncoder.Encode(base))) {
+				t.Fatalf("first draw wrote %q, want every cell", string(runes(first)))
+			}
+			if tt.reset {
+				s.Reset()
+			}
+
+			out := s.Draw(tt.width, height, frameWidth, tt.cells, tt.menu)
+			if got := runes(out); !slices.Equal(got, tt.runes) {
+				t.Errorf("drew cells %q, want %q", string(got), string(tt.runes))
+			}
+			if got := strings.Count(out, "H"); got != len(tt.moves) {
+				t.Errorf("drew %q with %d cursor moves, want %d", out, got, len(tt.moves))
+			}
+			for _, move := range tt.moves {
+				if !strings.Contains(out, move) {
+					t.Errorf("drew %q, want %q in it", out, move)
+				}
+			}
+		})
+	}
+}
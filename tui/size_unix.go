@@ -0,0 +1,44 @@
+//go:build !windows
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// openTTY opens the terminal the program runs in.
+func openTTY() (*os.File, error) {
+	return os.Open("/dev/tty")
+}
+
+// resizes sends the size of the terminal to p, then again whenever it is
+// resized, until stop is called.
+func resizes(p *tea.Program) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+				p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+			}
+
+			select {
+			case <-signals:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package tui
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// openTTY opens the console the program runs in.
+func openTTY() (*os.File, error) {
+	return os.OpenFile("CONIN$", os.O_RDWR, 0o644)
+}
+
+// resizes sends the size of the console to p. Windows has no signal for
+// resizes, the size is only sent once.
+func resizes(p *tea.Program) (stop func()) {
+	go func() {
+		if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+		}
+	}()
+
+	return func() {}
+}
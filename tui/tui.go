@@ -1,11 +1,17 @@
 package tui
 
 import (
+	"context"
 	"log"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/pipeline"
 	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
 )
 
 type mode = int
@@ -17,21 +23,6 @@ const (
 	COMMAND
 )
 
-const (
-	BG        = "255;255;255"
-	NORMAL_FG = "0;255;0"
-	NORMAL_BG = "100;100;100"
-
-	VISUAL_FG = "0;155;0"
-	VISUAL_BG = "100;255;0"
-
-	INSERT_FG = "0;255;100"
-	INSERT_BG = "0;255;255"
-
-	COMMAND_FG = "0;255;200"
-	COMMAND_BG = "200;255;0"
-)
-
 type model struct {
 	frame   frame
 	editor  editor
@@ -40,12 +31,84 @@ type model struct {
 	width, height int
 	menuWidth     int
 	mode          mode
+	options       Options
 
 	stack []any
+
+	player player
+	zoom   zoom
+
+	// pipeline is the last valid pipeline of the editor, which renders go
+	// through.
+	pipeline pipeline.Pipeline
+
+	// cancel aborts the render in progress, nil when there is none.
+	cancel context.CancelFunc
+	// renders counts the renders started, to discard results of aborted ones.
+	renders int
+
+	// status is shown on the last line of the menu, such as the error of the
+	// last update.
+	status string
+
+	// screen draws the model in place of bubbletea's renderer, nil with
+	// Options.FullRedraw.
+	screen *screen
+}
+
+// updateMsg carries an update received on Options.Updates.
+type updateMsg Update
+
+// wait returns the command receiving the next update, nil without updates.
+func (this *model) wait() tea.Cmd {
+	updates := this.options.Updates
+	if updates == nil {
+		return nil
+	}
+
+	return func() tea.Msg {
+		update, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return updateMsg(update)
+	}
+}
+
+// renderedMsg carries the result of a render started by model.render.
+type renderedMsg struct {
+	id    int
+	plane any
+	image filters.Ascii
+	err   error
+}
+
+// render runs job in the background and returns the command delivering its
+// result. A render already in progress is aborted.
+func (this *model) render(job func(ctx context.Context) (any, filters.Ascii, error)) tea.Cmd {
+	this.abort()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	this.cancel = cancel
+	this.renders++
+	id := this.renders
+
+	return func() tea.Msg {
+		plane, image, err := job(ctx)
+		return renderedMsg{id: id, plane: plane, image: image, err: err}
+	}
+}
+
+// abort cancels the render in progress, if any.
+func (this *model) abort() {
+	if this.cancel != nil {
+		this.cancel()
+		this.cancel = nil
+	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.player.Tick(), m.wait())
 }
 
 func (this *model) Run() tea.Cmd {
@@ -54,11 +117,19 @@ func (this *model) Run() tea.Cmd {
 		return tea.Quit
 
 	case "invert":
+		if len(this.stack) == 0 {
+			break
+		}
+
 		switch plane := this.stack[len(this.stack)-1].(type) {
-		case filters.GrayScalePlane:
-			pln := plane.Inverse()
-			this.stack = append(this.stack, pln)
-			this.frame.SetImage(pln.Braille(255 / 2))
+		case *filters.GrayScalePlane:
+			return this.render(func(ctx context.Context) (any, filters.Ascii, error) {
+				pln, err := plane.InverseContext(ctx)
+				if err != nil {
+					return nil, nil, err
+				}
+				return pln, pln.Braille(255 / 2), ctx.Err()
+			})
 		}
 	}
 
@@ -66,16 +137,83 @@ func (this *model) Run() tea.Cmd {
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+	if m.screen != nil {
+		m.draw()
+	}
+
+	return m, cmd
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case renderedMsg:
+		if msg.id != m.renders {
+			break // result of an aborted render
+		}
+
+		m.cancel = nil
+		if msg.err == nil {
+			m.stack = append(m.stack, msg.plane)
+			m.frame.SetImage(msg.image)
+			m.player = player{} // the render replaces the animation
+		}
+
+	case zoomedMsg:
+		return m, m.updateZoom(msg)
+
+	case updateMsg:
+		if msg.Err != nil {
+			m.status = " " + strings.ReplaceAll(msg.Err.Error(), "\n", " ")
+			return m, m.wait()
+		}
+
+		m.status = msg.Status
+		ticks := m.player.ticks + 1 // drops the tick of the previous frames
+		m.player = Player(msg.Frames, msg.Delays, msg.LoopCount)
+		m.player.ticks = ticks
+		m.frame.SetImage(m.player.Frame())
+		if msg.Pipeline != nil {
+			m.pipeline = msg.Pipeline
+			m.editor.SetPipeline(msg.Pipeline)
+		}
+
+		// The renders of the previous frames are stale, those at the
+		// current zoom are made again.
+		update := Update(msg)
+		m.zoom.Reset(&update)
+		var zoom tea.Cmd
+		if factor := m.zoom.factor; factor != 1 {
+			m.zoom.factor = 1
+			zoom = m.zoomTo(factor)
+		} else if m.options.Render != nil {
+			m.abort()
+			m.renders++
+		}
+		return m, tea.Batch(m.player.Tick(), m.wait(), zoom)
+
+	case tickMsg:
+		if m.player.Advance(msg) {
+			m.frame.SetImage(m.player.Frame())
+			return m, m.player.Tick()
+		}
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		m.frame.Resize(m.width-m.menuWidth, m.height)
 		m.editor.Height(m.height - 2)
 		m.command.Width(m.menuWidth)
-		m.editor.Width(m.menuWidth)
+		m.editor.Width(m.menuWidth - 1)
 
 	case tea.KeyMsg:
+		if m.mode == NORMAL || m.mode == VISUAL {
+			var bound bool
+			if msg, bound = m.options.translate(msg); !bound {
+				break
+			}
+		}
+
 		switch m.mode {
 		case NORMAL:
 			switch msg.String() {
@@ -87,19 +225,57 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.mode = COMMAND
 
 			case "i":
+				if m.options.Render == nil {
+					m.status = " the pipeline of this render cannot be edited"
+					break
+				}
 				m.mode = INSERT
+				m.editor.focus = true
+
+			case "+":
+				return m, m.zoomTo(m.zoom.factor * zoomStep)
+
+			case "-":
+				return m, m.zoomTo(m.zoom.factor / zoomStep)
+
+			case "=":
+				return m, m.zoomTo(m.fit())
+
+			case tea.KeyEsc.String():
+				m.abort()
 
 			default:
-				m.editor.Update(msg)
+				if m.player.Keys(msg.String()) {
+					changed, cmd := m.player.Update(msg)
+					if changed {
+						m.frame.SetImage(m.player.Frame())
+					}
+					return m, cmd
+				}
 			}
 
 		case INSERT:
 			switch msg.String() {
 			case tea.KeyEsc.String():
 				m.mode = NORMAL
+				m.editor.focus = false
 
 			default:
-				m.editor.Update(msg)
+				if !m.editor.Update(msg) {
+					break
+				}
+
+				pipe, err := m.editor.Pipeline()
+				if err != nil {
+					m.status = " " + err.Error()
+					break
+				}
+
+				// Every zoom is rendered again through the new pipeline.
+				m.status = ""
+				m.pipeline = pipe
+				m.zoom.Reset(nil)
+				return m, m.zoomTo(m.zoom.factor)
 			}
 
 		case VISUAL:
@@ -108,6 +284,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.command.Init()
 				m.mode = COMMAND
 
+			case "+":
+				return m, m.zoomTo(m.zoom.factor * zoomStep)
+
+			case "-":
+				return m, m.zoomTo(m.zoom.factor / zoomStep)
+
+			case "=":
+				return m, m.zoomTo(m.fit())
+
 			case tea.KeyEsc.String():
 				m.mode = NORMAL
 
@@ -134,53 +319,137 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// menu returns the lines on the right of the frame, from the separator on.
+func (m model) menu() []string {
+	out := make([]string, 0, m.height)
+	for _, line := range m.editor.View()[:m.height-2] {
+		out = append(out, "|"+line)
+	}
+
+	theme := m.options.Theme
+	switch m.mode {
+	case NORMAL:
+		out = append(out, "|\x1b[38;2;"+theme.NormalFG+"m\x1b[48;2;"+theme.NormalBG+"m NORMAL \x1b[48;2;"+theme.Background+"m"+strings.Repeat(" ", m.menuWidth-9)+"\x1b[0m")
+
+	case VISUAL:
+		out = append(out, "|\x1b[38;2;"+theme.VisualFG+"m\x1b[48;2;"+theme.VisualBG+"m VISUAL \x1b[48;2;"+theme.Background+"m"+strings.Repeat(" ", m.menuWidth-9)+"\x1b[0m")
+
+	case INSERT:
+		out = append(out, "|\x1b[38;2;"+theme.InsertFG+"m\x1b[48;2;"+theme.InsertBG+"m INSERT \x1b[48;2;"+theme.Background+"m"+strings.Repeat(" ", m.menuWidth-9)+"\x1b[0m")
+
+	case COMMAND:
+		out = append(out, "|\x1b[38;2;"+theme.CommandFG+"m\x1b[48;2;"+theme.CommandBG+"m COMMAND \x1b[48;2;"+theme.Background+"m"+strings.Repeat(" ", m.menuWidth-10)+"\x1b[0m")
+	}
+
+	switch {
+	case m.cancel != nil && m.mode != COMMAND:
+		out = append(out, "|"+fit(" rendering, esc to abort", m.menuWidth))
+	case m.status != "" && m.mode != COMMAND:
+		out = append(out, "|"+fit(m.status, m.menuWidth))
+	case m.player.Animated() && m.mode != COMMAND:
+		out = append(out, "|"+fit(m.player.View(), m.menuWidth))
+	default:
+		out = append(out, "|"+m.command.View())
+	}
+
+	return out
+}
+
 func (m model) View() string {
-	if m.width < 4 || m.height < 4 {
+	if m.width < 4 || m.height < 4 || m.screen != nil {
 		return ""
 	}
 
 	var str strings.Builder
 	frame := m.frame.View()
-	editor := m.editor.View()
+	for i, line := range m.menu() {
+		if i > 0 {
+			str.WriteString("\n")
+		}
+		str.WriteString(frame[i] + "\x1b[0m" + line)
+	}
 
-	for i := range m.height - 2 {
-		str.WriteString(frame[i] + "\x1b[0m|" + editor[i] + "\n")
+	return str.String()
+}
+
+// draw writes the changes of the screen since the last draw.
+func (this *model) draw() {
+	if this.width < 4 || this.height < 4 {
+		return
 	}
 
-	str.WriteString(frame[m.height-2] + "\x1b[0m|")
-	switch m.mode {
-	case NORMAL:
-		str.WriteString("\x1b[38;2;" + NORMAL_FG + "m\x1b[48;2;" + NORMAL_BG + "m NORMAL \x1b[48;2;" + BG + "m" + strings.Repeat(" ", m.menuWidth-9))
+	cells := this.frame.Cells()
+	if cells == nil {
+		// The image cannot be diffed, its lines are written in full.
+		this.screen.Reset()
+		frame := this.frame.View()
+		for i, line := range this.menu() {
+			os.Stdout.WriteString("\x1b[" + strconv.Itoa(i+1) + "H" + frame[i] + "\x1b[0m" + line)
+		}
+		return
+	}
 
-	case VISUAL:
-		str.WriteString("\x1b[38;2;" + VISUAL_FG + "m\x1b[48;2;" + VISUAL_BG + "m VISUAL \x1b[48;2;" + BG + "m" + strings.Repeat(" ", m.menuWidth-9))
+	os.Stdout.WriteString(this.screen.Draw(this.width, this.height, this.width-this.menuWidth, cells, this.menu()))
+}
 
-	case INSERT:
-		str.WriteString("\x1b[38;2;" + INSERT_FG + "m\x1b[48;2;" + INSERT_BG + "m INSERT \x1b[48;2;" + BG + "m" + strings.Repeat(" ", m.menuWidth-9))
+func Start(image filters.Ascii, options Options) {
+	Play([]filters.Ascii{image}, []time.Duration{0}, -1, options)
+}
 
-	case COMMAND:
-		str.WriteString("\x1b[38;2;" + COMMAND_FG + "m\x1b[48;2;" + COMMAND_BG + "m COMMAND \x1b[48;2;" + BG + "m" + strings.Repeat(" ", m.menuWidth-10))
+// Play shows the frames of an animation, each for its delay, as many times as
+// loopCount says, as in image/gif. Space pauses and resumes playback, '.' and
+// ',' step forward and backward and 'o' toggles looping forever. With Options.Render, '+' and '-' zoom in and out, '=' fits the
+// frames to the window and the pipeline is edited in INSERT mode.
+func Play(frames []filters.Ascii, delays []time.Duration, loopCount int, options Options) {
+	m := newModel(frames, delays, loopCount, options)
+
+	run := runRendered
+	if !options.FullRedraw {
+		m.screen = &screen{}
+		run = runDirect
 	}
-	str.WriteString("\x1b[0m\n")
 
-	str.WriteString(frame[m.height-1] + "\x1b[0m|" + m.command.View())
-	return str.String()
+	if err := run(m); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func Start(image filters.Ascii) {
-	p := tea.NewProgram(model{
-		frame:   Frame(0, 0, image),
-		editor:  Editor(),
+// newModel returns the model showing frames, before any draw.
+func newModel(frames []filters.Ascii, delays []time.Duration, loopCount int, options Options) model {
+	m := model{
+		frame:   Frame(0, 0, frames[0]),
+		editor:  Editor(options.Pipeline),
 		command: Command(),
+		player:  Player(frames, delays, loopCount),
+		zoom:    zoom{factor: 1},
+
+		pipeline: options.Pipeline,
 
 		width: 0, height: 0,
 		mode:      NORMAL,
-		menuWidth: 55,
-	}, tea.WithAltScreen())
+		menuWidth: options.MenuWidth,
+		options:   options,
+	}
 
-	tea.WindowSize()
+	m.zoom.Reset(&Update{Frames: frames, Delays: delays, LoopCount: loopCount})
 
-	if _, err := p.Run(); err != nil {
-		log.Fatal(err)
+	// Commands such as :invert start from the image the frames were
+	// rendered from.
+	if options.Gray != nil {
+		m.stack = append(m.stack, options.Gray)
+	}
+
+	return m
+}
+
+// runRendered runs m through bubbletea's renderer.
+func runRendered(m model) error {
+	programOptions := []tea.ProgramOption{tea.WithAltScreen()}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		// stdin carried the image, read keys from the terminal itself.
+		programOptions = append(programOptions, tea.WithInputTTY())
 	}
+
+	_, err := tea.NewProgram(m, programOptions...).Run()
+	return err
 }
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gradient returns a gray plane whose pixels go from black to white.
+func gradient(width, height int) *filters.GrayScalePlane {
+	out := filters.NewGrayScalePlane(width, height)
+	for i := range out.Shades {
+		out.Shades[i] = float64(i % 256)
+	}
+
+	return out
+}
+
+// invert enters :invert into m and returns the command rendering it.
+func invert(t *testing.T, m model) (model, tea.Cmd) {
+	t.Helper()
+
+	m, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	for _, r := range "invert" {
+		m, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m, cmd := m.update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal(":invert started no render")
+	}
+
+	return m, cmd
+}
+
+func TestInvert(t *testing.T) {
+	gray := gradient(64, 64)
+	image := gray.Braille(127)
+
+	options := DefaultOptions
+	options.Gray = gray
+
+	// The render goes through.
+	m, cmd := invert(t, newModel([]filters.Ascii{image}, []time.Duration{0}, -1, options))
+	m, _ = m.update(cmd())
+	if len(m.stack) != 2 || m.frame.src == filters.Ascii(image) {
+		t.Fatalf("stack of %d planes after :invert, want 2 and the inverted image shown", len(m.stack))
+	}
+	if inverted := m.stack[1].(*filters.GrayScalePlane); inverted.Shades[1] != 255-gray.Shades[1] {
+		t.Errorf("inverted pixel = %v, want %v", inverted.Shades[1], 255-gray.Shades[1])
+	}
+
+	// Esc aborts it before it is done.
+	m, cmd = invert(t, newModel([]filters.Ascii{image}, []time.Duration{0}, -1, options))
+	m, _ = m.update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.cancel != nil {
+		t.Error("esc left the render in progress")
+	}
+
+	msg := cmd()
+	if rendered, ok := msg.(renderedMsg); !ok || !errors.Is(rendered.err, context.Canceled) {
+		t.Fatalf("aborted render gives %#v, want context.Canceled", msg)
+	}
+	m, _ = m.update(msg)
+	if len(m.stack) != 1 || m.frame.src != filters.Ascii(image) {
+		t.Errorf("aborted render changed the stack to %d planes or the image shown", len(m.stack))
+	}
+}
+
+func TestInvertWithoutGray(t *testing.T) {
+	m := newModel([]filters.Ascii{filters.NewAsciiPlane(4, 4)}, []time.Duration{0}, -1, DefaultOptions)
+
+	m.command.cmd = "invert"
+	if m.Run() != nil {
+		t.Error(":invert rendered without an image to invert")
+	}
+}
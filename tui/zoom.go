@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// zoomStep is the factor '+' and '-' zoom by, two steps doubling the size.
+const zoomStep = math.Sqrt2
+
+// minZoom and maxZoom bound the zoom, relative to the render given to the
+// viewer.
+const minZoom, maxZoom = 1. / 16, 16.
+
+// zoom is the scale of the frames shown along with the renders made at every
+// scale so far, which zooming back to shows at once.
+type zoom struct {
+	factor float64
+	cache  map[int]Update
+}
+
+// key returns the key of factor in the cache, rounded so that zooming in and
+// back out finds the render it started from.
+func (zoom) key(factor float64) int {
+	return int(math.Round(factor * 1000))
+}
+
+// Reset forgets every render but update, made at a factor of 1, nil
+// forgetting them all.
+func (this *zoom) Reset(update *Update) {
+	this.cache = map[int]Update{}
+	if update != nil {
+		this.cache[this.key(1)] = *update
+	}
+}
+
+// zoomedMsg carries the render started by model.zoomTo.
+type zoomedMsg struct {
+	id     int
+	factor float64
+	update Update
+	err    error
+}
+
+// zoomTo shows the frames rendered factor times as large as the render given
+// to the viewer, rendering them unless they were already. It does nothing
+// without Options.Render.
+func (this *model) zoomTo(factor float64) tea.Cmd {
+	render, pipe := this.options.Render, this.pipeline
+	if render == nil {
+		return nil
+	}
+
+	factor = max(minZoom, min(factor, maxZoom))
+	if update, ok := this.zoom.cache[this.zoom.key(factor)]; ok {
+		this.abort()
+		this.renders++ // discards the render of another factor in progress
+		this.zoomed(factor, update)
+		return this.player.Tick()
+	}
+
+	this.abort()
+	ctx, cancel := context.WithCancel(context.Background())
+	this.cancel = cancel
+	this.renders++
+	id := this.renders
+
+	return func() tea.Msg {
+		update, err := render(ctx, pipe, factor)
+		return zoomedMsg{id: id, factor: factor, update: update, err: err}
+	}
+}
+
+// fit returns the factor at which the frames fill the frame without
+// overflowing it.
+func (this *model) fit() float64 {
+	image := this.frame.src
+	if image.Width_() == 0 || image.Height_() == 0 {
+		return this.zoom.factor
+	}
+
+	scale := min(float64(this.frame.width)/float64(image.Width_()), float64(this.frame.height)/float64(image.Height_()))
+	return this.zoom.factor * scale
+}
+
+// zoomed shows update, rendered at factor, keeping the frame played and the
+// source pixel at the center of the viewport.
+func (this *model) zoomed(factor float64, update Update) {
+	x, y := this.frame.Center()
+
+	index, paused, ticks := this.player.index, this.player.paused, this.player.ticks+1
+	this.player = Player(update.Frames, update.Delays, update.LoopCount)
+	this.player.index = min(index, len(update.Frames)-1)
+	this.player.paused, this.player.ticks = paused, ticks
+
+	this.zoom.factor = factor
+	this.frame.SetImage(this.player.Frame())
+	this.frame.CenterOn(x, y)
+}
+
+// updateZoom handles the result of a render started by zoomTo.
+func (this *model) updateZoom(msg zoomedMsg) tea.Cmd {
+	if msg.id != this.renders {
+		return nil // result of an aborted render
+	}
+
+	this.cancel = nil
+	if msg.err != nil {
+		if !errors.Is(msg.err, context.Canceled) {
+			this.status = " " + strings.ReplaceAll(msg.err.Error(), "\n", " ")
+		}
+		return nil
+	}
+
+	this.zoom.cache[this.zoom.key(msg.factor)] = msg.update
+	this.zoomed(msg.factor, msg.update)
+	return this.player.Tick()
+}
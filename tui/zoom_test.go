@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/filters"
+	"github.com/IJJA3141/GoSCII/pipeline"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestZoomKey(t *testing.T) {
+	var z zoom
+
+	for _, tt := range []struct {
+		name  string
+		steps int
+	}{
+		{"in and out", 4},
+		{"out and in", -4},
+		{"to the bounds", 8},
+	} {
+		factor := 1.
+		for range max(tt.steps, -tt.steps) {
+			factor *= math.Pow(zoomStep, math.Copysign(1, float64(tt.steps)))
+		}
+		for range max(tt.steps, -tt.steps) {
+			factor /= math.Pow(zoomStep, math.Copysign(1, float64(tt.steps)))
+		}
+
+		if z.key(factor) != z.key(1) {
+			t.Errorf("%s: factor %v has key %d, want that of 1, %d", tt.name, factor, z.key(factor), z.key(1))
+		}
+	}
+
+	if z.key(zoomStep) == z.key(1) || z.key(1/zoomStep) == z.key(1) {
+		t.Error("a step of zoom does not change the key")
+	}
+}
+
+func TestFrameCenter(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// width and height are those of the image, scale that of the image
+		// the frame is centered on again.
+		width, height int
+		x, y          int
+		scale         int
+		wantX, wantY  int
+	}{
+		{"same image", 100, 60, 30, 20, 1, 30, 20},
+		{"twice as large", 100, 60, 30, 20, 2, 70, 45},
+		{"thrice as large", 100, 60, 30, 20, 3, 110, 70},
+		{"top left corner", 100, 60, 0, 0, 2, 10, 5},
+		{"bottom right corner", 100, 60, 80, 50, 2, 170, 105},
+		{"smaller than the frame", 10, 5, 0, 0, 4, 10, 5},
+	} {
+		f := Frame(20, 10, filters.NewAsciiPlane(tt.width, tt.height))
+		f.x, f.y = tt.x, tt.y
+
+		x, y := f.Center()
+		f.SetImage(filters.NewAsciiPlane(tt.width*tt.scale, tt.height*tt.scale))
+		f.CenterOn(x, y)
+
+		if f.x != tt.wantX || f.y != tt.wantY {
+			t.Errorf("%s: viewport at %d,%d, want %d,%d", tt.name, f.x, f.y, tt.wantX, tt.wantY)
+		}
+
+		// Centering on the point the viewport is centered on keeps it.
+		x, y = f.Center()
+		f.CenterOn(x, y)
+		if f.x != tt.wantX || f.y != tt.wantY {
+			t.Errorf("%s: viewport moved to %d,%d, want %d,%d", tt.name, f.x, f.y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestZoomTo(t *testing.T) {
+	var factors []float64
+
+	options := DefaultOptions
+	options.Pipeline = pipeline.MustParse("gray | braille")
+	options.Render = func(ctx context.Context, pipe pipeline.Pipeline, factor float64) (Update, error) {
+		factors = append(factors, factor)
+		size := int(math.Round(40 * factor))
+		return Update{Frames: []filters.Ascii{filters.NewAsciiPlane(size, size)}, Delays: []time.Duration{0}}, nil
+	}
+
+	m := newModel([]filters.Ascii{filters.NewAsciiPlane(40, 40)}, []time.Duration{0}, -1, options)
+	m, _ = m.update(tea.WindowSizeMsg{Width: 20 + m.menuWidth, Height: 10})
+
+	// zoom goes to factor, through the render it starts if any.
+	zoom := func(factor float64) {
+		t.Helper()
+
+		// Cached renders are shown at once, the command only ticking the
+		// player of animations.
+		if cmd := m.zoomTo(factor); cmd != nil {
+			if msg, ok := cmd().(zoomedMsg); ok {
+				m, _ = m.update(msg)
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		factor, want float64
+		renders      int
+	}{
+		{zoomStep, zoomStep, 1},
+		{zoomStep * zoomStep, 2, 2},
+		{2 / zoomStep, zoomStep, 2},
+		{1, 1, 2},
+		{2, 2, 2},
+		{100, maxZoom, 3},
+		{0, minZoom, 4},
+	} {
+		zoom(tt.factor)
+
+		if m.zoom.factor != max(minZoom, min(tt.factor, maxZoom)) || m.zoom.key(m.zoom.factor) != m.zoom.key(tt.want) {
+			t.Errorf("zoomTo(%v) zoomed to %v, want %v", tt.factor, m.zoom.factor, tt.want)
+		}
+		if len(factors) != tt.renders {
+			t.Errorf("zoomTo(%v) made %d renders in all, want %d: %v", tt.factor, len(factors), tt.renders, factors)
+		}
+		if want := int(math.Round(40 * tt.want)); m.frame.src.Width_() != want {
+			t.Errorf("zoomTo(%v) shows an image %d wide, want %d", tt.factor, m.frame.src.Width_(), want)
+		}
+	}
+
+	// A new pipeline forgets every render.
+	m.zoom.Reset(nil)
+	zoom(1)
+	if len(factors) != 5 {
+		t.Errorf("zoomTo(1) after Reset made no render")
+	}
+}
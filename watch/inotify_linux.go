@@ -0,0 +1,66 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotify watches the directories of paths, pinging on the events touching
+// one of the files.
+func (this *Watcher) inotify(paths []string) error {
+	watched, err := dirs(paths)
+	if err != nil {
+		return err
+	}
+
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+
+	// Reads go through the runtime poller, so that closing the file unblocks
+	// them.
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	const mask = unix.IN_CLOSE_WRITE | unix.IN_MODIFY | unix.IN_CREATE | unix.IN_MOVED_TO | unix.IN_DELETE | unix.IN_MOVED_FROM
+	names := map[int32]map[string]bool{}
+	for dir, files := range watched {
+		wd, err := unix.InotifyAddWatch(fd, dir, mask)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		names[int32(wd)] = files
+	}
+
+	this.stop = file.Close
+
+	go func() {
+		buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return // closed
+			}
+
+			for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+				event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				raw := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+				offset += unix.SizeofInotifyEvent + int(event.Len)
+
+				name := string(bytes.TrimRight(raw, "\x00"))
+				if names[event.Wd][name] {
+					this.ping()
+				}
+			}
+		}
+	}()
+
+	return nil
+}
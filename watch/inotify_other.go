@@ -0,0 +1,10 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+// inotify is only available on Linux, New polls elsewhere.
+func (this *Watcher) inotify(paths []string) error {
+	return errors.New("watch: inotify is not available")
+}
@@ -0,0 +1,190 @@
+// Package watch reports changes of files, through inotify on Linux and by
+// polling elsewhere or when inotify is not available.
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Watcher sends on C once the files it watches changed and then stayed
+// untouched for its debounce delay, so that a file being written is reported
+// once, after the last write.
+type Watcher struct {
+	// C receives a value per batch of changes. Changes happening while a
+	// value is pending are merged into it.
+	C <-chan struct{}
+
+	c     chan struct{}
+	pings chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	// stop releases the resources of the backend.
+	stop func() error
+}
+
+// DefaultInterval is how often files are polled without inotify.
+const DefaultInterval = 250 * time.Millisecond
+
+// New watches paths, falling back to polling when inotify is not available.
+// Files are watched through their directory, which keeps working when
+// editors replace them instead of writing them in place.
+func New(paths []string, debounce time.Duration) (*Watcher, error) {
+	w, err := newWatcher(paths, debounce)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.inotify(paths); err != nil {
+		w.poll(paths, DefaultInterval)
+	}
+
+	return w, nil
+}
+
+// NewPolling watches paths by checking their size and modification time
+// every interval.
+func NewPolling(paths []string, debounce, interval time.Duration) (*Watcher, error) {
+	w, err := newWatcher(paths, debounce)
+	if err != nil {
+		return nil, err
+	}
+
+	w.poll(paths, interval)
+	return w, nil
+}
+
+func newWatcher(paths []string, debounce time.Duration) (*Watcher, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("watch: no file to watch")
+	}
+	for _, path := range paths {
+		if path == "-" {
+			return nil, errors.New("watch: cannot watch the standard input")
+		}
+	}
+
+	c := make(chan struct{}, 1)
+	w := &Watcher{
+		C:     c,
+		c:     c,
+		pings: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+
+	go w.debounce(debounce)
+	return w, nil
+}
+
+// Close stops watching and closes C.
+func (this *Watcher) Close() error {
+	var err error
+	this.once.Do(func() {
+		close(this.done)
+		if this.stop != nil {
+			err = this.stop()
+		}
+	})
+
+	return err
+}
+
+// ping tells the debouncer something changed.
+func (this *Watcher) ping() {
+	select {
+	case this.pings <- struct{}{}:
+	default:
+	}
+}
+
+// debounce sends on C once no ping came for delay.
+func (this *Watcher) debounce(delay time.Duration) {
+	defer close(this.c)
+
+	timer := time.NewTimer(delay)
+	timer.Stop()
+
+	for {
+		select {
+		case <-this.done:
+			timer.Stop()
+			return
+
+		case <-this.pings:
+			timer.Reset(delay)
+
+		case <-timer.C:
+			select {
+			case this.c <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// stat is what polling compares files on.
+type stat struct {
+	size    int64
+	modTime time.Time
+	exists  bool
+}
+
+func statOf(path string) stat {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stat{}
+	}
+
+	return stat{size: info.Size(), modTime: info.ModTime(), exists: true}
+}
+
+// poll pings whenever the stat of a file changes.
+func (this *Watcher) poll(paths []string, interval time.Duration) {
+	last := make([]stat, len(paths))
+	for i, path := range paths {
+		last[i] = statOf(path)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-this.done:
+				return
+
+			case <-ticker.C:
+				for i, path := range paths {
+					if next := statOf(path); next != last[i] {
+						last[i] = next
+						this.ping()
+					}
+				}
+			}
+		}
+	}()
+}
+
+// dirs groups the base names of paths by directory.
+func dirs(paths []string) (map[string]map[string]bool, error) {
+	out := map[string]map[string]bool{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		dir, name := filepath.Split(abs)
+		if out[dir] == nil {
+			out[dir] = map[string]bool{}
+		}
+		out[dir][name] = true
+	}
+
+	return out, nil
+}
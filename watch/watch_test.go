@@ -0,0 +1,76 @@
+package watch_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IJJA3141/GoSCII/watch"
+)
+
+func testWatcher(t *testing.T, w *watch.Watcher, err error, path string) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// Several writes in a row are reported once, after the last one.
+	for i := range 3 {
+		if err := os.WriteFile(path, []byte{byte(i), byte(i)}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case <-w.C:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	select {
+	case <-w.C:
+		t.Error("writes were reported more than once")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// Replacing the file, as editors do, is a change too.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte("replaced"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-w.C:
+	case <-time.After(2 * time.Second):
+		t.Fatal("replacement not reported")
+	}
+
+	w.Close()
+	if _, ok := <-w.C; ok {
+		t.Error("C is still open after Close")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	w, err := watch.New([]string{path}, 100*time.Millisecond)
+	testWatcher(t, w, err, path)
+}
+
+func TestPolling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	w, err := watch.NewPolling([]string{path}, 100*time.Millisecond, 20*time.Millisecond)
+	testWatcher(t, w, err, path)
+}
+
+func TestStdin(t *testing.T) {
+	if _, err := watch.New([]string{"-"}, 0); err == nil {
+		t.Error("stdin was watched")
+	}
+}